@@ -0,0 +1,174 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 告警类型
+const (
+	KindMatch    = "match"    // 日志命中告警规则
+	KindRepeat   = "repeat"   // 去重窗口内的重复汇总
+	KindSilence  = "silence"  // 日志静默
+	KindRecovery = "recovery" // 日志从静默中恢复
+	KindSilenced = "silenced" // 告警屏蔽窗口结束后的汇总
+)
+
+// 告警级别，从低到高
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// SeverityRank 告警级别的高低，未知级别返回0
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityCritical:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Alert 告警内容，作为消息模板的渲染数据
+type Alert struct {
+	Kind       string            `json:"kind"`                  // 告警类型
+	Title      string            `json:"title"`                 // 标题，如 "🚨 日志告警"
+	File       string            `json:"file"`                  // 日志文件路径
+	Hostname   string            `json:"hostname"`              // 主机名
+	Rule       string            `json:"rule,omitempty"`        // 命中的规则名称，默认规则为空
+	Severity   string            `json:"severity,omitempty"`    // 告警级别：info、warning 或 critical
+	Keyword    string            `json:"keyword,omitempty"`     // 命中的关键词或正则表达式
+	Line       string            `json:"line"`                  // 日志内容（多行事件为完整事件）
+	LineNumber int64             `json:"line_number,omitempty"` // 行号（多行事件为第一行的行号），未知时为0
+	Fields     map[string]string `json:"fields,omitempty"`      // 正则命名捕获组
+	Time       time.Time         `json:"time"`                  // 告警时间
+	Summary    string            `json:"summary,omitempty"`     // 次数统计说明，如 "最近 5 分钟内重复 10 次"
+	Samples    []string          `json:"samples,omitempty"`     // 样例行
+	Mention    *Mention          `json:"mention,omitempty"`     // 规则配置的 @提醒，为nil时使用通知器的配置
+	Notifiers  []string          `json:"-"`                     // 告警路由的通知器名称，为空时使用默认路由
+}
+
+// Mention 告警需要 @ 的人员
+type Mention struct {
+	Mobiles []string `json:"mobiles,omitempty"`  // 钉钉、企业微信手机号
+	UserIDs []string `json:"user_ids,omitempty"` // 钉钉、企业微信 userId
+	OpenIDs []string `json:"open_ids,omitempty"` // 飞书 open_id
+	All     bool     `json:"all,omitempty"`      // @所有人
+}
+
+// Empty 是否没有需要 @ 的人员
+func (m *Mention) Empty() bool {
+	return m == nil || (len(m.Mobiles) == 0 && len(m.UserIDs) == 0 && len(m.OpenIDs) == 0 && !m.All)
+}
+
+// DefaultTemplate 默认消息模板
+const DefaultTemplate = `{{.Title}}
+
+文件: {{.File}}
+主机: {{.Hostname}}
+时间: {{.Time.Format "2006-01-02 15:04:05"}}
+{{- if .Rule}}
+规则: {{.Rule}}{{end}}
+{{- if .Severity}}
+级别: {{.Severity}}{{end}}
+{{- if .LineNumber}}
+行号: {{.LineNumber}}{{end}}
+内容: {{.Line}}
+{{- with .Fields}}
+字段: {{fields .}}{{end}}
+{{- if .Summary}}
+次数: {{.Summary}}{{end}}
+{{- with .Samples}}
+样例:
+{{join . "\n"}}{{end}}`
+
+// funcs 模板中可用的辅助函数
+var funcs = template.FuncMap{
+	"fields": FormatFields,
+	"json":   toJSON,
+	"join":   strings.Join,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+}
+
+// ParseTemplate 解析消息模板，并用示例告警试渲染，以便在加载配置时发现字段名错误等问题
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析模板失败: %v", err)
+	}
+	if _, err := Render(tmpl, Sample()); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Render 渲染告警消息
+func Render(tmpl *template.Template, a *Alert) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, a); err != nil {
+		return "", fmt.Errorf("渲染模板失败: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// FormatFields 按字段名排序格式化捕获字段
+func FormatFields(fields map[string]string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+"="+fields[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// toJSON 将值编码为 JSON，用于在 JSON 请求体模板中安全地嵌入字符串
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// sampleLine 示例日志行，包含引号、反斜杠和换行，未转义输出到 JSON 模板时会被校验发现
+const sampleLine = "ERROR: request \"GET /api\" failed: C:\\app\\data\n\tat main.run"
+
+// Sample 用于校验模板的示例告警，所有字段均有值
+func Sample() *Alert {
+	return &Alert{
+		Kind:       KindMatch,
+		Title:      "🚨 日志告警",
+		File:       "/var/log/app/application.log",
+		Hostname:   "localhost",
+		Rule:       "example",
+		Severity:   SeverityWarning,
+		Keyword:    "ERROR",
+		Line:       sampleLine,
+		LineNumber: 1,
+		Fields:     map[string]string{"status": "500"},
+		Time:       time.Now(),
+		Summary:    "最近 1 分钟内命中 1 次",
+		Samples:    []string{sampleLine},
+	}
+}
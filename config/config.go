@@ -1,41 +1,256 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"log-monitor/alert"
 )
 
 // Config 主配置结构
 type Config struct {
-	LogFiles       []LogFile      `yaml:"log_files"`
-	LogDirectories []LogDirectory `yaml:"log_directories"`
-	Notifiers      []Notifier     `yaml:"notifiers"`
+	LogFiles         []LogFile      `yaml:"log_files"`
+	LogDirectories   []LogDirectory `yaml:"log_directories"`
+	Notifiers        []Notifier     `yaml:"notifiers"`
+	DefaultNotifiers []string       `yaml:"default_notifiers,omitempty"` // 默认路由：没有配置 notifiers 的告警发送到的通知器，未配置时发送到所有通知器
+	Checkpoint       *Checkpoint    `yaml:"checkpoint,omitempty"`        // 读取进度检查点，未配置时每次启动从文件末尾开始
+	Dedup            *Dedup         `yaml:"dedup,omitempty"`             // 告警去重，未配置时每条命中都发送
+	Template         string         `yaml:"template,omitempty"`          // 全局默认消息模板（Go text/template），未配置时使用内置模板
+	Delivery         *Delivery      `yaml:"delivery,omitempty"`          // 通知发送重试与死信队列，未配置时使用默认重试策略
+	Silences         []Silence      `yaml:"silences,omitempty"`          // 告警屏蔽（维护窗口、免打扰时段）
+	SilenceFile      string         `yaml:"silence_file,omitempty"`      // 运行时告警屏蔽文件，由 silence 子命令写入，运行中自动重新加载
+}
+
+// Checkpoint 读取进度检查点配置
+type Checkpoint struct {
+	Path     string        `yaml:"path"`               // 检查点文件路径
+	Interval time.Duration `yaml:"interval,omitempty"` // 保存间隔 (默认10s)
 }
 
 // LogFile 日志文件配置
 type LogFile struct {
-	Path     string   `yaml:"path"`
-	Keywords []string `yaml:"keywords"`
-	Enabled  bool     `yaml:"enabled"`
+	Path                 string        `yaml:"path"`
+	Keywords             []Match       `yaml:"keywords"`                         // 关键词，可以为单个关键词配置告警级别
+	Patterns             []Match       `yaml:"patterns,omitempty"`               // 正则表达式匹配规则，支持命名捕获组
+	Rules                []Rule        `yaml:"rules,omitempty"`                  // 命名告警规则，优先于 keywords/patterns 匹配
+	ExcludeKeywords      []string      `yaml:"exclude_keywords,omitempty"`       // 排除关键词，命中的行即使匹配告警规则也会被丢弃
+	ExcludePatterns      []string      `yaml:"exclude_patterns,omitempty"`       // 排除正则规则
+	Multiline            *Multiline    `yaml:"multiline,omitempty"`              // 多行事件合并（如异常堆栈）
+	ExpectActivityWithin time.Duration `yaml:"expect_activity_within,omitempty"` // 超过该时长没有新日志则告警（静默检测）
+	ExpectPattern        string        `yaml:"expect_pattern,omitempty"`         // 静默检测期望周期性出现的日志（正则），如 "health check passed"
+	Notifiers            []string      `yaml:"notifiers,omitempty"`              // 告警发送到的通知器名称，未配置时使用默认路由
+	Severity             string        `yaml:"severity,omitempty"`               // keywords/patterns 和静默检测的告警级别 (默认warning)，单个关键词配置的级别优先
+	Enabled              bool          `yaml:"enabled"`
 }
 
 // LogDirectory 日志目录配置
 type LogDirectory struct {
-	Path        string   `yaml:"path"`
-	Keywords    []string `yaml:"keywords"`
-	Extensions  []string `yaml:"extensions"`  // 支持的文件扩展名，如 [".log", ".txt"]
-	Recursive   bool     `yaml:"recursive"`   // 是否递归监控子目录
-	ExcludeDirs []string `yaml:"exclude_dirs,omitempty"` // 排除的子目录
-	Enabled     bool     `yaml:"enabled"`
+	Path                 string        `yaml:"path"`
+	Keywords             []Match       `yaml:"keywords"`                         // 关键词，可以为单个关键词配置告警级别
+	Patterns             []Match       `yaml:"patterns,omitempty"`               // 正则表达式匹配规则，支持命名捕获组
+	Rules                []Rule        `yaml:"rules,omitempty"`                  // 命名告警规则，优先于 keywords/patterns 匹配
+	Extensions           []string      `yaml:"extensions"`                       // 支持的文件扩展名，如 [".log", ".txt"]
+	Recursive            bool          `yaml:"recursive"`                        // 是否递归监控子目录
+	ExcludeDirs          []string      `yaml:"exclude_dirs,omitempty"`           // 排除的子目录
+	ExcludeKeywords      []string      `yaml:"exclude_keywords,omitempty"`       // 排除关键词，命中的行即使匹配告警规则也会被丢弃
+	ExcludePatterns      []string      `yaml:"exclude_patterns,omitempty"`       // 排除正则规则
+	Multiline            *Multiline    `yaml:"multiline,omitempty"`              // 多行事件合并（如异常堆栈）
+	ExpectActivityWithin time.Duration `yaml:"expect_activity_within,omitempty"` // 超过该时长没有新日志则告警（静默检测）
+	ExpectPattern        string        `yaml:"expect_pattern,omitempty"`         // 静默检测期望周期性出现的日志（正则），如 "health check passed"
+	Notifiers            []string      `yaml:"notifiers,omitempty"`              // 告警发送到的通知器名称，未配置时使用默认路由
+	Severity             string        `yaml:"severity,omitempty"`               // keywords/patterns 和静默检测的告警级别 (默认warning)，单个关键词配置的级别优先
+	Enabled              bool          `yaml:"enabled"`
+}
+
+// Rule 命名告警规则
+type Rule struct {
+	Name      string     `yaml:"name"`
+	Keywords  []Match    `yaml:"keywords,omitempty"`
+	Patterns  []Match    `yaml:"patterns,omitempty"`
+	Threshold *Threshold `yaml:"threshold,omitempty"` // 阈值，未配置时每次命中都告警
+	Mention   *Mention   `yaml:"mention,omitempty"`   // @提醒，配置后覆盖通知器的 @提醒配置
+	Notifiers []string   `yaml:"notifiers,omitempty"` // 告警发送到的通知器名称，配置后覆盖日志文件/目录的路由
+	Severity  string     `yaml:"severity,omitempty"`  // 告警级别，未配置时使用日志文件/目录的级别，单个关键词配置的级别优先
+}
+
+// Match 关键词或正则规则，可以直接写字符串，也可以写成 {match: "FATAL", severity: critical} 单独配置告警级别
+type Match struct {
+	Value    string `yaml:"match"`
+	Severity string `yaml:"severity,omitempty"` // 命中时的告警级别，未配置时使用所属规则或日志文件/目录的级别
+}
+
+// UnmarshalYAML 支持字符串和映射两种写法
+func (m *Match) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*m = Match{}
+		return value.Decode(&m.Value)
+	}
+	type plain Match
+	return value.Decode((*plain)(m))
+}
+
+// Mention @提醒配置
+type Mention struct {
+	AtMobiles []string `yaml:"at_mobiles,omitempty"`  // 钉钉、企业微信：按手机号 @
+	AtUserIDs []string `yaml:"at_user_ids,omitempty"` // 钉钉、企业微信：按 userId @
+	AtOpenIDs []string `yaml:"at_open_ids,omitempty"` // 飞书：按 open_id @
+	AtAll     bool     `yaml:"at_all,omitempty"`      // @所有人
+}
+
+// Threshold 阈值配置：窗口内命中次数达到 count 时才告警
+type Threshold struct {
+	Count  int           `yaml:"count"`
+	Window time.Duration `yaml:"window"`
+}
+
+// Dedup 告警去重配置
+// 同一文件中屏蔽数字、UUID、时间戳后内容相同的告警视为重复，窗口内只发送第一条和一条汇总
+type Dedup struct {
+	Window time.Duration `yaml:"window,omitempty"` // 去重窗口 (默认5m)
+}
+
+// Delivery 通知发送重试与死信队列配置
+// 网络错误、5xx 和 429 响应按指数退避（带随机抖动）重试，最终失败的告警写入死信队列，
+// 通知器恢复后（包括重启后）按顺序重放
+type Delivery struct {
+	MaxAttempts    int           `yaml:"max_attempts,omitempty"`    // 最大发送次数，包含首次发送 (默认3)
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"` // 首次重试等待时间 (默认1s)
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`     // 最大重试等待时间 (默认30s)
+	QueueDir       string        `yaml:"queue_dir,omitempty"`       // 死信队列目录，未配置时最终失败的告警只记录日志
+	QueueMaxSize   int           `yaml:"queue_max_size,omitempty"`  // 每个通知器最多保留的告警数 (默认1000)，超出时丢弃最早的告警
+	ReplayInterval time.Duration `yaml:"replay_interval,omitempty"` // 死信队列重放间隔 (默认1m)
+}
+
+// RouteName 通知器在告警路由中的名称，未配置名称时为类型
+func (n *Notifier) RouteName() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.Type
+}
+
+// AlertMention 转换为告警中的 @提醒，未配置任何人员时返回nil
+func (m *Mention) AlertMention() *alert.Mention {
+	if m == nil {
+		return nil
+	}
+	mention := &alert.Mention{
+		Mobiles: m.AtMobiles,
+		UserIDs: m.AtUserIDs,
+		OpenIDs: m.AtOpenIDs,
+		All:     m.AtAll,
+	}
+	if mention.Empty() {
+		return nil
+	}
+	return mention
+}
+
+// Multiline 多行事件合并配置
+// start_pattern 命中的行开始一个新事件，continuation_pattern 命中的行追加到当前事件，
+// 只配置 start_pattern 时所有不匹配的行都视为续行
+type Multiline struct {
+	StartPattern        string        `yaml:"start_pattern,omitempty"`        // 事件起始行正则
+	ContinuationPattern string        `yaml:"continuation_pattern,omitempty"` // 续行正则
+	MaxLines            int           `yaml:"max_lines,omitempty"`            // 单个事件最大行数 (默认500)
+	FlushTimeout        time.Duration `yaml:"flush_timeout,omitempty"`        // 等待续行的超时时间 (默认2s)
 }
 
 // Notifier 通知器配置
 type Notifier struct {
-	Type    string `yaml:"type"`    // "feishu" 或 "dingtalk"
-	Webhook string `yaml:"webhook"`
-	Secret  string `yaml:"secret,omitempty"`
-	Enabled bool   `yaml:"enabled"`
+	Name         string            `yaml:"name,omitempty"`         // 名称，用于告警路由，未配置时为类型
+	MinSeverity  string            `yaml:"min_severity,omitempty"` // 发送的最低告警级别，未配置时发送所有告警
+	Type         string            `yaml:"type"`                   // "feishu"、"dingtalk"、"wecom"、"slack"、"teams"、"telegram"、"webhook"、"alertmanager"、"email"、"exec" 或 "file"
+	Webhook      string            `yaml:"webhook"`
+	Secret       string            `yaml:"secret,omitempty"`
+	Format       string            `yaml:"format,omitempty"`       // 消息格式：text（默认，slack/teams 默认为 card，telegram 默认为 markdown）、card、markdown、adaptive、html
+	CardURL      string            `yaml:"card_url,omitempty"`     // 钉钉 actionCard 按钮跳转链接（如日志平台地址）
+	Template     string            `yaml:"template,omitempty"`     // 消息模板，用于 text 格式及卡片被拒绝时的降级消息
+	Method       string            `yaml:"method,omitempty"`       // 通用 webhook：请求方法 (默认POST)
+	Headers      map[string]string `yaml:"headers,omitempty"`      // 通用 webhook、alertmanager：附加请求头
+	BearerToken  string            `yaml:"bearer_token,omitempty"` // 通用 webhook、alertmanager：Bearer 认证令牌
+	ContentType  string            `yaml:"content_type,omitempty"` // 通用 webhook：Content-Type (默认application/json)
+	Body         string            `yaml:"body,omitempty"`         // 通用 webhook：请求体模板，未配置时发送告警的 JSON
+	SMTP         *SMTP             `yaml:"smtp,omitempty"`         // 邮件通知器：SMTP 服务器和收件人
+	Telegram     *Telegram         `yaml:"telegram,omitempty"`     // Telegram 通知器：机器人和会话
+	Exec         *Exec             `yaml:"exec,omitempty"`         // 命令通知器：执行的命令
+	Alertmanager *Alertmanager     `yaml:"alertmanager,omitempty"` // Alertmanager 通知器：标签和恢复时间
+	File         *FileSink         `yaml:"file,omitempty"`         // 文件通知器：告警记录文件
+	Mention      `yaml:",inline"`  // @提醒，规则配置了 mention 时以规则为准
+	Enabled      bool              `yaml:"enabled"`
+}
+
+// Exec 命令通知器配置，告警以 JSON 写入命令的标准输入，同时通过 LOG_MONITOR_* 环境变量传递
+type Exec struct {
+	Command       []string      `yaml:"command"`                  // 命令及参数，不经过 shell 解析
+	Dir           string        `yaml:"dir,omitempty"`            // 工作目录
+	Timeout       time.Duration `yaml:"timeout,omitempty"`        // 超时时间 (默认30s)，超时后终止命令
+	MaxConcurrent int           `yaml:"max_concurrent,omitempty"` // 最大并发执行数 (默认1)
+}
+
+// Alertmanager Alertmanager 通知器配置，webhook 为 Alertmanager 地址
+type Alertmanager struct {
+	ResolveTimeout time.Duration     `yaml:"resolve_timeout,omitempty"` // 告警最后一次出现后多久自动恢复 (默认5m)
+	ResendInterval time.Duration     `yaml:"resend_interval,omitempty"` // 持续中的告警重发间隔 (默认为 resolve_timeout 的一半)
+	Labels         map[string]string `yaml:"labels,omitempty"`          // 附加标签，如 team、env
+	GeneratorURL   string            `yaml:"generator_url,omitempty"`   // 告警来源链接（如日志平台地址）
+}
+
+// Alertmanager 恢复时间和重发间隔的默认值与下限
+const (
+	DefaultAlertmanagerResolveTimeout = 5 * time.Minute  // 默认告警自动恢复时间
+	MinAlertmanagerResendInterval     = 10 * time.Second // 最小重发间隔，配置的间隔更小时按该值重发
+)
+
+// Intervals 实际生效的恢复时间和重发间隔，重发间隔默认为恢复时间的一半且不小于 MinAlertmanagerResendInterval
+func (am *Alertmanager) Intervals() (resolveTimeout, resendInterval time.Duration) {
+	resolveTimeout = DefaultAlertmanagerResolveTimeout
+	if am != nil && am.ResolveTimeout > 0 {
+		resolveTimeout = am.ResolveTimeout
+	}
+	if am != nil && am.ResendInterval > 0 {
+		resendInterval = am.ResendInterval
+	} else {
+		// Alertmanager 要求在 endsAt 之前重发，默认为恢复时间的一半
+		resendInterval = resolveTimeout / 2
+	}
+	if resendInterval < MinAlertmanagerResendInterval {
+		resendInterval = MinAlertmanagerResendInterval
+	}
+	return resolveTimeout, resendInterval
+}
+
+// FileSink 文件通知器配置，每条告警及其投递结果以 JSON 行追加到文件中
+type FileSink struct {
+	Path       string        `yaml:"path"`                  // 记录文件路径
+	MaxSizeMB  int           `yaml:"max_size_mb,omitempty"` // 单个文件最大大小，超出后轮转 (默认100)
+	MaxBackups int           `yaml:"max_backups,omitempty"` // 保留的轮转文件数 (默认10)
+	MaxAge     time.Duration `yaml:"max_age,omitempty"`     // 轮转文件保留时间，未配置时不按时间清理
+}
+
+// Telegram Telegram 通知器配置
+type Telegram struct {
+	BotToken string `yaml:"bot_token"`
+	ChatID   string `yaml:"chat_id"`           // 会话 ID，群组为负数，频道可以使用 @channelname
+	APIURL   string `yaml:"api_url,omitempty"` // Bot API 地址 (默认https://api.telegram.org)，可指向自建 Bot API 服务或本地测试服务
+}
+
+// SMTP 邮件通知器配置
+type SMTP struct {
+	Host               string   `yaml:"host"`
+	Port               int      `yaml:"port,omitempty"` // 端口 (默认：tls 为465，其余为587)
+	Username           string   `yaml:"username,omitempty"`
+	Password           string   `yaml:"password,omitempty"`
+	From               string   `yaml:"from"`
+	To                 []string `yaml:"to"`
+	TLS                string   `yaml:"tls,omitempty"`                  // starttls（默认）、tls（隐式TLS）或 none
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify,omitempty"` // 跳过服务器证书校验
+	Subject            string   `yaml:"subject,omitempty"`              // 邮件主题模板 (默认 "{{.Title}} {{.File}}")
 }
 
 // LoadConfig 加载配置文件
@@ -68,8 +283,27 @@ func (c *Config) Validate() error {
 		if logFile.Path == "" {
 			return fmt.Errorf("日志文件[%d]路径不能为空", i)
 		}
-		if len(logFile.Keywords) == 0 {
-			return fmt.Errorf("日志文件[%d]关键词不能为空", i)
+		if len(logFile.Keywords) == 0 && len(logFile.Patterns) == 0 && len(logFile.Rules) == 0 &&
+			logFile.ExpectActivityWithin == 0 {
+			return fmt.Errorf("日志文件[%d]关键词、正则规则、告警规则和静默检测不能同时为空", i)
+		}
+		if err := validateMatches(logFile.Keywords, logFile.Patterns); err != nil {
+			return fmt.Errorf("日志文件[%d]%v", i, err)
+		}
+		if err := validatePatterns(logFile.ExcludePatterns); err != nil {
+			return fmt.Errorf("日志文件[%d]排除%v", i, err)
+		}
+		if err := validateRules(logFile.Rules); err != nil {
+			return fmt.Errorf("日志文件[%d]%v", i, err)
+		}
+		if err := validateMultiline(logFile.Multiline); err != nil {
+			return fmt.Errorf("日志文件[%d]%v", i, err)
+		}
+		if err := validateExpectActivity(logFile.ExpectActivityWithin, logFile.ExpectPattern); err != nil {
+			return fmt.Errorf("日志文件[%d]%v", i, err)
+		}
+		if err := validateSeverity(logFile.Severity); err != nil {
+			return fmt.Errorf("日志文件[%d]%v", i, err)
 		}
 	}
 
@@ -77,22 +311,484 @@ func (c *Config) Validate() error {
 		if logDir.Path == "" {
 			return fmt.Errorf("日志目录[%d]路径不能为空", i)
 		}
-		if len(logDir.Keywords) == 0 {
-			return fmt.Errorf("日志目录[%d]关键词不能为空", i)
+		if len(logDir.Keywords) == 0 && len(logDir.Patterns) == 0 && len(logDir.Rules) == 0 &&
+			logDir.ExpectActivityWithin == 0 {
+			return fmt.Errorf("日志目录[%d]关键词、正则规则、告警规则和静默检测不能同时为空", i)
+		}
+		if err := validateMatches(logDir.Keywords, logDir.Patterns); err != nil {
+			return fmt.Errorf("日志目录[%d]%v", i, err)
+		}
+		if err := validatePatterns(logDir.ExcludePatterns); err != nil {
+			return fmt.Errorf("日志目录[%d]排除%v", i, err)
+		}
+		if err := validateRules(logDir.Rules); err != nil {
+			return fmt.Errorf("日志目录[%d]%v", i, err)
+		}
+		if err := validateMultiline(logDir.Multiline); err != nil {
+			return fmt.Errorf("日志目录[%d]%v", i, err)
+		}
+		if err := validateExpectActivity(logDir.ExpectActivityWithin, logDir.ExpectPattern); err != nil {
+			return fmt.Errorf("日志目录[%d]%v", i, err)
+		}
+		if err := validateSeverity(logDir.Severity); err != nil {
+			return fmt.Errorf("日志目录[%d]%v", i, err)
 		}
 		if len(logDir.Extensions) == 0 {
 			return fmt.Errorf("日志目录[%d]必须指定至少一个文件扩展名", i)
 		}
 	}
 
-	for i, notifier := range c.Notifiers {
-		if notifier.Type != "feishu" && notifier.Type != "dingtalk" {
-			return fmt.Errorf("通知器[%d]类型必须是 feishu 或 dingtalk", i)
+	if c.Checkpoint != nil {
+		if c.Checkpoint.Path == "" {
+			return fmt.Errorf("检查点文件路径不能为空")
+		}
+		if c.Checkpoint.Interval < 0 {
+			return fmt.Errorf("检查点保存间隔不能为负数")
+		}
+	}
+
+	if c.Dedup != nil && c.Dedup.Window < 0 {
+		return fmt.Errorf("去重窗口不能为负数")
+	}
+
+	if err := validateDelivery(c.Delivery); err != nil {
+		return err
+	}
+
+	if c.Template != "" {
+		if _, err := alert.ParseTemplate("default", c.Template); err != nil {
+			return fmt.Errorf("全局消息模板无效: %v", err)
+		}
+	}
+
+	for i := range c.Notifiers {
+		if err := validateNotifier(&c.Notifiers[i]); err != nil {
+			return fmt.Errorf("通知器[%d]%v", i, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Silences {
+		if err := c.Silences[i].Validate(); err != nil {
+			return fmt.Errorf("告警屏蔽[%d]%v", i, err)
+		}
+		if names[c.Silences[i].Name] {
+			return fmt.Errorf("告警屏蔽[%d]名称 %s 重复", i, c.Silences[i].Name)
+		}
+		names[c.Silences[i].Name] = true
+	}
+
+	if err := c.validateRoutes(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateRoutes 校验通知器名称不重复，以及告警路由引用的通知器存在且已启用
+// 同一类型的多个未命名通知器共用类型作为名称，显式配置的名称不能与其他通知器的名称相同
+func (c *Config) validateRoutes() error {
+	configured := make(map[string]bool)
+	explicit := make(map[string]bool)
+	enabled := make(map[string]bool)
+	for i, n := range c.Notifiers {
+		name := n.RouteName()
+		if configured[name] && (n.Name != "" || explicit[name]) {
+			return fmt.Errorf("通知器[%d]名称 %s 重复", i, name)
+		}
+		configured[name] = true
+		if n.Name != "" {
+			explicit[name] = true
+		}
+		if n.Enabled {
+			enabled[name] = true
+		}
+	}
+
+	check := func(refs []string) error {
+		for _, ref := range refs {
+			if !configured[ref] {
+				return fmt.Errorf("引用的通知器 %s 不存在", ref)
+			}
+			if !enabled[ref] {
+				return fmt.Errorf("引用的通知器 %s 未启用", ref)
+			}
+		}
+		return nil
+	}
+
+	if err := check(c.DefaultNotifiers); err != nil {
+		return fmt.Errorf("default_notifiers %v", err)
+	}
+	for i := range c.Silences {
+		if err := c.ValidateSilenceScope(&c.Silences[i]); err != nil {
+			return fmt.Errorf("告警屏蔽 %s %v", c.Silences[i].Name, err)
+		}
+	}
+	for i, logFile := range c.LogFiles {
+		if err := check(logFile.Notifiers); err != nil {
+			return fmt.Errorf("日志文件[%d]%v", i, err)
+		}
+		for _, rule := range logFile.Rules {
+			if err := check(rule.Notifiers); err != nil {
+				return fmt.Errorf("日志文件[%d]告警规则 %s %v", i, rule.Name, err)
+			}
+		}
+	}
+	for i, logDir := range c.LogDirectories {
+		if err := check(logDir.Notifiers); err != nil {
+			return fmt.Errorf("日志目录[%d]%v", i, err)
+		}
+		for _, rule := range logDir.Rules {
+			if err := check(rule.Notifiers); err != nil {
+				return fmt.Errorf("日志目录[%d]告警规则 %s %v", i, rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateSilenceScope 校验告警屏蔽引用的通知器和告警规则已配置
+// 屏蔽只是不发送，允许引用暂时禁用的通知器
+func (c *Config) ValidateSilenceScope(s *Silence) error {
+	notifiers := make(map[string]bool)
+	for i := range c.Notifiers {
+		notifiers[c.Notifiers[i].RouteName()] = true
+	}
+	for _, ref := range s.Notifiers {
+		if !notifiers[ref] {
+			return fmt.Errorf("引用的通知器 %s 不存在", ref)
+		}
+	}
+
+	rules := make(map[string]bool)
+	for _, logFile := range c.LogFiles {
+		for _, rule := range logFile.Rules {
+			rules[rule.Name] = true
+		}
+	}
+	for _, logDir := range c.LogDirectories {
+		for _, rule := range logDir.Rules {
+			rules[rule.Name] = true
+		}
+	}
+	for _, ref := range s.Rules {
+		if !rules[ref] {
+			return fmt.Errorf("引用的告警规则 %s 不存在", ref)
+		}
+	}
+	return nil
+}
+
+// validatePatterns 校验正则表达式是否合法
+func validatePatterns(patterns []string) error {
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("正则规则[%d]无效: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// validateMatches 校验关键词和正则规则不为空、正则合法以及配置的告警级别有效
+func validateMatches(keywords, patterns []Match) error {
+	for i, k := range keywords {
+		if k.Value == "" {
+			return fmt.Errorf("关键词[%d]不能为空", i)
+		}
+		if err := validateSeverity(k.Severity); err != nil {
+			return fmt.Errorf("关键词 %s %v", k.Value, err)
+		}
+	}
+	for i, p := range patterns {
+		if p.Value == "" {
+			return fmt.Errorf("正则规则[%d]不能为空", i)
+		}
+		if _, err := regexp.Compile(p.Value); err != nil {
+			return fmt.Errorf("正则规则[%d]无效: %v", i, err)
+		}
+		if err := validateSeverity(p.Severity); err != nil {
+			return fmt.Errorf("正则规则 %s %v", p.Value, err)
+		}
+	}
+	return nil
+}
+
+// validateRules 校验命名告警规则
+func validateRules(rules []Rule) error {
+	names := make(map[string]bool)
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("告警规则[%d]名称不能为空", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("告警规则[%d]名称 %s 重复", i, rule.Name)
+		}
+		names[rule.Name] = true
+
+		if len(rule.Keywords) == 0 && len(rule.Patterns) == 0 {
+			return fmt.Errorf("告警规则 %s 关键词和正则规则不能同时为空", rule.Name)
+		}
+		if err := validateMatches(rule.Keywords, rule.Patterns); err != nil {
+			return fmt.Errorf("告警规则 %s %v", rule.Name, err)
+		}
+		if err := validateSeverity(rule.Severity); err != nil {
+			return fmt.Errorf("告警规则 %s %v", rule.Name, err)
+		}
+		if rule.Threshold != nil {
+			if rule.Threshold.Count <= 0 {
+				return fmt.Errorf("告警规则 %s 阈值次数必须大于0", rule.Name)
+			}
+			if rule.Threshold.Window <= 0 {
+				return fmt.Errorf("告警规则 %s 阈值窗口必须大于0", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDelivery 校验通知发送重试与死信队列配置
+func validateDelivery(d *Delivery) error {
+	if d == nil {
+		return nil
+	}
+	if d.MaxAttempts < 0 {
+		return fmt.Errorf("delivery.max_attempts 不能为负数")
+	}
+	if d.InitialBackoff < 0 || d.MaxBackoff < 0 {
+		return fmt.Errorf("delivery 重试等待时间不能为负数")
+	}
+	if d.InitialBackoff > 0 && d.MaxBackoff > 0 && d.InitialBackoff > d.MaxBackoff {
+		return fmt.Errorf("delivery.initial_backoff 不能大于 max_backoff")
+	}
+	if d.QueueMaxSize < 0 {
+		return fmt.Errorf("delivery.queue_max_size 不能为负数")
+	}
+	if d.ReplayInterval < 0 {
+		return fmt.Errorf("delivery.replay_interval 不能为负数")
+	}
+	return nil
+}
+
+// validateSeverity 校验告警级别，为空时使用默认级别
+func validateSeverity(severity string) error {
+	if severity != "" && alert.SeverityRank(severity) == 0 {
+		return fmt.Errorf("告警级别 %s 无效，必须是 info、warning 或 critical", severity)
+	}
+	return nil
+}
+
+// validateNotifier 校验通知器配置
+func validateNotifier(n *Notifier) error {
+	if err := validateSeverity(n.MinSeverity); err != nil {
+		return fmt.Errorf("min_severity %v", err)
+	}
+	needWebhook := true
+	var formats []string // 支持的消息格式，为空时不支持配置 format
+	switch n.Type {
+	case "feishu":
+		formats = []string{"text", "card", "markdown"}
+	case "dingtalk":
+		formats = []string{"text", "card", "markdown"}
+		if n.Format == "card" && n.CardURL == "" {
+			return fmt.Errorf("钉钉 actionCard 消息需要配置 card_url")
+		}
+	case "wecom":
+		formats = []string{"text", "markdown"}
+	case "slack":
+		formats = []string{"text", "card"}
+	case "teams":
+		formats = []string{"text", "card", "adaptive"}
+	case "webhook":
+		if err := validateWebhook(n); err != nil {
+			return err
+		}
+	case "telegram":
+		needWebhook = false
+		formats = []string{"text", "markdown"}
+		if n.Telegram == nil || n.Telegram.BotToken == "" || n.Telegram.ChatID == "" {
+			return fmt.Errorf("telegram 通知器需要配置 telegram.bot_token 和 telegram.chat_id")
+		}
+	case "alertmanager":
+		if err := validateAlertmanager(n.Alertmanager); err != nil {
+			return err
+		}
+	case "exec":
+		needWebhook = false
+		if n.Exec == nil || len(n.Exec.Command) == 0 || n.Exec.Command[0] == "" {
+			return fmt.Errorf("exec 通知器需要配置 exec.command")
+		}
+		if n.Exec.Timeout < 0 || n.Exec.MaxConcurrent < 0 {
+			return fmt.Errorf("exec.timeout 和 exec.max_concurrent 不能为负数")
 		}
-		if notifier.Webhook == "" {
-			return fmt.Errorf("通知器[%d]webhook不能为空", i)
+	case "file":
+		needWebhook = false
+		if n.File == nil || n.File.Path == "" {
+			return fmt.Errorf("file 通知器需要配置 file.path")
 		}
+		if n.File.MaxSizeMB < 0 || n.File.MaxBackups < 0 || n.File.MaxAge < 0 {
+			return fmt.Errorf("file.max_size_mb、file.max_backups 和 file.max_age 不能为负数")
+		}
+	case "email":
+		needWebhook = false
+		formats = []string{"text", "html"}
+		if err := validateSMTP(n.SMTP); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("类型必须是 feishu、dingtalk、wecom、slack、teams、telegram、webhook、alertmanager、email、exec 或 file")
 	}
 
+	if needWebhook && n.Webhook == "" {
+		return fmt.Errorf("webhook不能为空")
+	}
+	if n.Format != "" && !contains(formats, n.Format) {
+		if len(formats) == 0 {
+			return fmt.Errorf("%s 通知器不支持配置消息格式", n.Type)
+		}
+		return fmt.Errorf("%s 通知器的消息格式必须是 %s", n.Type, strings.Join(formats, "、"))
+	}
+	if n.Template != "" {
+		if _, err := alert.ParseTemplate(n.Type, n.Template); err != nil {
+			return fmt.Errorf("消息模板无效: %v", err)
+		}
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// contains 判断字符串是否在列表中
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSMTP 校验邮件通知器配置
+func validateSMTP(smtp *SMTP) error {
+	if smtp == nil {
+		return fmt.Errorf("email 通知器需要配置 smtp")
+	}
+	if smtp.Host == "" {
+		return fmt.Errorf("smtp.host 不能为空")
+	}
+	if smtp.Port < 0 || smtp.Port > 65535 {
+		return fmt.Errorf("smtp.port 无效")
+	}
+	if smtp.From == "" {
+		return fmt.Errorf("smtp.from 不能为空")
+	}
+	if len(smtp.To) == 0 {
+		return fmt.Errorf("smtp.to 至少需要一个收件人")
+	}
+	switch smtp.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("smtp.tls 必须是 starttls、tls 或 none")
+	}
+	if smtp.Subject != "" {
+		if _, err := alert.ParseTemplate("subject", smtp.Subject); err != nil {
+			return fmt.Errorf("smtp.subject 模板无效: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateWebhook 校验通用 webhook 通知器配置，JSON 请求体模板需要渲染出合法的 JSON
+func validateWebhook(n *Notifier) error {
+	switch strings.ToUpper(n.Method) {
+	case "", "POST", "PUT", "PATCH":
+	default:
+		return fmt.Errorf("请求方法必须是 POST、PUT 或 PATCH")
+	}
+	if n.Body == "" {
+		return nil
+	}
+
+	tmpl, err := alert.ParseTemplate("body", n.Body)
+	if err != nil {
+		return fmt.Errorf("请求体模板无效: %v", err)
+	}
+	if n.ContentType == "" || strings.Contains(n.ContentType, "json") {
+		body, err := alert.Render(tmpl, alert.Sample())
+		if err != nil {
+			return fmt.Errorf("请求体模板无效: %v", err)
+		}
+		if !json.Valid([]byte(body)) {
+			return fmt.Errorf("请求体模板渲染结果不是合法的 JSON，字符串字段请使用 {{json .Line}} 形式")
+		}
+	}
+	return nil
+}
+
+// labelNamePattern Prometheus 标签名格式
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateAlertmanager 校验 Alertmanager 通知器配置
+func validateAlertmanager(am *Alertmanager) error {
+	if am == nil {
+		return nil
+	}
+	if am.ResolveTimeout < 0 || am.ResendInterval < 0 {
+		return fmt.Errorf("alertmanager.resolve_timeout 和 alertmanager.resend_interval 不能为负数")
+	}
+	resolveTimeout, resendInterval := am.Intervals()
+	if resolveTimeout <= MinAlertmanagerResendInterval {
+		return fmt.Errorf("alertmanager.resolve_timeout 必须大于 %v（最小重发间隔），否则告警会在重发前自动恢复", MinAlertmanagerResendInterval)
+	}
+	if resendInterval >= resolveTimeout {
+		return fmt.Errorf("alertmanager.resend_interval 必须小于 resolve_timeout，否则告警会在重发前自动恢复")
+	}
+	for name := range am.Labels {
+		if !labelNamePattern.MatchString(name) {
+			return fmt.Errorf("alertmanager 标签名 %q 无效，只能包含字母、数字和下划线且不能以数字开头", name)
+		}
+	}
+	return nil
+}
+
+// validateMultiline 校验多行事件合并配置
+func validateMultiline(ml *Multiline) error {
+	if ml == nil {
+		return nil
+	}
+	if ml.StartPattern == "" && ml.ContinuationPattern == "" {
+		return fmt.Errorf("多行配置必须指定 start_pattern 或 continuation_pattern")
+	}
+	if ml.StartPattern != "" {
+		if _, err := regexp.Compile(ml.StartPattern); err != nil {
+			return fmt.Errorf("多行配置 start_pattern 无效: %v", err)
+		}
+	}
+	if ml.ContinuationPattern != "" {
+		if _, err := regexp.Compile(ml.ContinuationPattern); err != nil {
+			return fmt.Errorf("多行配置 continuation_pattern 无效: %v", err)
+		}
+	}
+	if ml.MaxLines < 0 {
+		return fmt.Errorf("多行配置 max_lines 不能为负数")
+	}
+	if ml.FlushTimeout < 0 {
+		return fmt.Errorf("多行配置 flush_timeout 不能为负数")
+	}
+	return nil
+}
+
+// validateExpectActivity 校验静默检测配置
+func validateExpectActivity(within time.Duration, pattern string) error {
+	if within < 0 {
+		return fmt.Errorf("expect_activity_within 不能为负数")
+	}
+	if pattern == "" {
+		return nil
+	}
+	if within == 0 {
+		return fmt.Errorf("expect_pattern 需要同时配置 expect_activity_within")
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("expect_pattern 无效: %v", err)
+	}
+	return nil
+}
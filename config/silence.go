@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"log-monitor/internal/fileutil"
+)
+
+// Silence 告警屏蔽配置，窗口内匹配的告警不发送，只计数
+// 每天固定时段使用 start_time/end_time（可限定星期和时区），一次性窗口使用 starts_at/ends_at
+type Silence struct {
+	Name      string    `yaml:"name"`
+	Comment   string    `yaml:"comment,omitempty"`    // 备注，如变更单号
+	StartTime string    `yaml:"start_time,omitempty"` // 每天开始时间，如 "22:00"
+	EndTime   string    `yaml:"end_time,omitempty"`   // 每天结束时间，早于开始时间时表示跨天
+	Weekdays  []string  `yaml:"weekdays,omitempty"`   // 生效的星期（跨天时按开始时间所在的日期计算），如 ["sat", "sun"]，未配置时每天生效
+	Timezone  string    `yaml:"timezone,omitempty"`   // 时区，如 "Asia/Shanghai"，未配置时使用本地时区
+	StartsAt  time.Time `yaml:"starts_at,omitempty"`  // 一次性窗口开始时间
+	EndsAt    time.Time `yaml:"ends_at,omitempty"`    // 一次性窗口结束时间
+	Files     []string  `yaml:"files,omitempty"`      // 屏蔽的日志文件（支持通配符），未配置时屏蔽所有文件
+	Rules     []string  `yaml:"rules,omitempty"`      // 屏蔽的告警规则，未配置时屏蔽所有规则
+	Notifiers []string  `yaml:"notifiers,omitempty"`  // 屏蔽的通知器名称，未配置时屏蔽所有通知器
+	Summary   bool      `yaml:"summary,omitempty"`    // 窗口结束时发送屏蔽期间的告警汇总
+}
+
+// Recurring 是否为每天固定时段的屏蔽
+func (s *Silence) Recurring() bool {
+	return s.StartTime != "" || s.EndTime != ""
+}
+
+// Validate 校验告警屏蔽配置
+func (s *Silence) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("名称不能为空")
+	}
+
+	absolute := !s.StartsAt.IsZero() || !s.EndsAt.IsZero()
+	switch {
+	case s.Recurring() && absolute:
+		return fmt.Errorf("%s 不能同时配置 start_time/end_time 和 starts_at/ends_at", s.Name)
+	case s.Recurring():
+		from, err := ParseClock(s.StartTime)
+		if err != nil {
+			return fmt.Errorf("%s start_time %v", s.Name, err)
+		}
+		to, err := ParseClock(s.EndTime)
+		if err != nil {
+			return fmt.Errorf("%s end_time %v", s.Name, err)
+		}
+		if from == to {
+			return fmt.Errorf("%s start_time 和 end_time 不能相同", s.Name)
+		}
+		for _, day := range s.Weekdays {
+			if _, err := ParseWeekday(day); err != nil {
+				return fmt.Errorf("%s %v", s.Name, err)
+			}
+		}
+		if s.Timezone != "" {
+			if _, err := time.LoadLocation(s.Timezone); err != nil {
+				return fmt.Errorf("%s 时区 %s 无效: %v", s.Name, s.Timezone, err)
+			}
+		}
+	case absolute:
+		if s.StartsAt.IsZero() || s.EndsAt.IsZero() {
+			return fmt.Errorf("%s 需要同时配置 starts_at 和 ends_at", s.Name)
+		}
+		if !s.EndsAt.After(s.StartsAt) {
+			return fmt.Errorf("%s ends_at 必须晚于 starts_at", s.Name)
+		}
+		if len(s.Weekdays) > 0 || s.Timezone != "" {
+			return fmt.Errorf("%s 一次性窗口不支持 weekdays 和 timezone", s.Name)
+		}
+	default:
+		return fmt.Errorf("%s 需要配置 start_time/end_time 或 starts_at/ends_at", s.Name)
+	}
+
+	for _, pattern := range s.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s 文件通配符 %s 无效: %v", s.Name, pattern, err)
+		}
+	}
+	return nil
+}
+
+// ParseClock 解析 "HH:MM" 格式的时间，返回距零点的分钟数
+func ParseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("时间 %q 格式错误，应为 HH:MM", s)
+	}
+	hour, err1 := strconv.Atoi(parts[0])
+	minute, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("时间 %q 格式错误，应为 HH:MM", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// weekdayNames 星期的英文名称及缩写
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseWeekday 解析星期名称，如 "mon"、"Monday"
+func ParseWeekday(s string) (time.Weekday, error) {
+	day, ok := weekdayNames[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("星期 %q 无效，应为 mon、tue、wed、thu、fri、sat 或 sun", s)
+	}
+	return day, nil
+}
+
+// LoadSilences 读取运行时告警屏蔽文件，文件不存在时返回空列表
+func LoadSilences(path string) ([]Silence, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取告警屏蔽文件失败: %v", err)
+	}
+
+	var silences []Silence
+	if err := yaml.Unmarshal(data, &silences); err != nil {
+		return nil, fmt.Errorf("解析告警屏蔽文件失败: %v", err)
+	}
+	return silences, nil
+}
+
+// SaveSilences 保存运行时告警屏蔽文件，先写临时文件再重命名，避免监控进程读到不完整的文件
+func SaveSilences(path string, silences []Silence) error {
+	data, err := yaml.Marshal(silences)
+	if err != nil {
+		return fmt.Errorf("序列化告警屏蔽失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建告警屏蔽目录失败: %v", err)
+	}
+	if err := fileutil.WriteAtomic(path, data); err != nil {
+		return fmt.Errorf("写入告警屏蔽文件失败: %v", err)
+	}
+	return nil
+}
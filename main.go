@@ -13,6 +13,12 @@ import (
 )
 
 func main() {
+	// silence 子命令：添加运行时告警屏蔽
+	if len(os.Args) > 1 && os.Args[1] == "silence" {
+		runSilence(os.Args[2:])
+		return
+	}
+
 	// 解析命令行参数
 	configPath := flag.String("config", "config.yaml", "配置文件路径")
 	flag.Parse()
@@ -31,7 +37,10 @@ func main() {
 	log.Printf("配置加载成功，监控 %d 个日志文件，配置 %d 个通知器", len(cfg.LogFiles), len(cfg.Notifiers))
 
 	// 创建通知器
-	notifiers := notifier.CreateNotifiers(cfg.Notifiers)
+	notifiers, err := notifier.CreateNotifiers(cfg)
+	if err != nil {
+		log.Fatalf("创建通知器失败: %v", err)
+	}
 	if len(notifiers) == 0 {
 		log.Fatalf("没有可用的通知器")
 	}
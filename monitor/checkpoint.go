@@ -0,0 +1,213 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"log-monitor/internal/fileutil"
+)
+
+const (
+	checkpointVersion         = 1                // 检查点文件格式版本
+	defaultCheckpointInterval = 10 * time.Second // 默认检查点保存间隔
+)
+
+// checkpointFile 检查点文件内容
+type checkpointFile struct {
+	Version int                        `json:"version"`
+	SavedAt time.Time                  `json:"saved_at"`
+	Files   map[string]checkpointEntry `json:"files"`
+}
+
+// checkpointEntry 单个文件的读取进度
+type checkpointEntry struct {
+	Offset int64 `json:"offset"`
+	Lines  int64 `json:"lines,omitempty"` // offset 之前的行数，0 表示未统计
+	fileIdentity
+}
+
+// loadCheckpoint 加载检查点文件，文件不存在时视为首次启动
+func (m *LogMonitor) loadCheckpoint() error {
+	if m.config.Checkpoint == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.config.Checkpoint.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取检查点文件失败: %v", err)
+	}
+
+	var cp checkpointFile
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("解析检查点文件失败: %v", err)
+	}
+	if cp.Version != checkpointVersion {
+		return fmt.Errorf("不支持的检查点文件版本: %d", cp.Version)
+	}
+
+	m.checkpoints = cp.Files
+	m.checkpointSavedAt = cp.SavedAt
+	log.Printf("加载检查点成功: %d 个文件 (保存于 %s)",
+		len(cp.Files), cp.SavedAt.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// saveCheckpoint 保存当前读取进度，先写临时文件再重命名保证原子性
+func (m *LogMonitor) saveCheckpoint() error {
+	if m.config.Checkpoint == nil {
+		return nil
+	}
+
+	cp := checkpointFile{
+		Version: checkpointVersion,
+		SavedAt: time.Now(),
+		Files:   make(map[string]checkpointEntry),
+	}
+	m.mu.RLock()
+	for filePath, state := range m.files {
+		if state.id.Fingerprint == "" {
+			continue
+		}
+		entry := checkpointEntry{Offset: state.pos, fileIdentity: state.id}
+		if state.linesKnown() {
+			entry.Lines = state.lines
+		}
+		cp.Files[filePath] = entry
+	}
+	m.mu.RUnlock()
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化检查点失败: %v", err)
+	}
+
+	if err := fileutil.WriteAtomic(m.config.Checkpoint.Path, data); err != nil {
+		return fmt.Errorf("写入检查点失败: %v", err)
+	}
+	return nil
+}
+
+// checkpointLoop 定期保存检查点
+func (m *LogMonitor) checkpointLoop() {
+	interval := m.config.Checkpoint.Interval
+	if interval <= 0 {
+		interval = defaultCheckpointInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.saveCheckpoint(); err != nil {
+				log.Printf("保存检查点失败: %v", err)
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// initFilePos 初始化文件读取位置
+// 检查点中记录的是同一个文件时从检查点位置继续读取；文件在停机期间被轮转时先读完轮转走的旧文件，新文件从头读取；
+// 检查点中没有记录、且修改时间晚于检查点保存时间的文件是停机期间新建的，从头读取；
+// 其余情况（首次启动、新增的监控配置）从文件末尾开始，避免重复处理历史日志
+func (m *LogMonitor) initFilePos(filePath string) {
+	m.mu.RLock()
+	_, tracked := m.files[filePath]
+	m.mu.RUnlock()
+	if tracked {
+		// 已作为其他文件轮转后的文件恢复了读取位置
+		return
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	state := fileState{pos: stat.Size()}
+	var rotatedPath string
+	var rotatedState fileState
+	if entry, exists := m.checkpoints[filePath]; exists {
+		if entry.Offset <= stat.Size() && entry.sameFile(file, stat) {
+			state = fileState{pos: entry.Offset, lines: entry.Lines, counted: entry.Lines > 0}
+			if state.pos < stat.Size() {
+				log.Printf("从检查点恢复 %s (位置: %d, 待读取: %d bytes)",
+					filePath, state.pos, stat.Size()-state.pos)
+			}
+		} else {
+			state = fileState{}
+			rotatedPath = m.findRotatedFile(filepath.Dir(filePath), entry.fileIdentity)
+			if rotatedPath == filePath {
+				rotatedPath = ""
+			}
+			rotatedState = fileState{pos: entry.Offset, lines: entry.Lines, counted: entry.Lines > 0, id: entry.fileIdentity}
+			log.Printf("文件 %s 在停机期间被轮转或替换，从文件开头开始监控", filePath)
+		}
+	} else if entry, ok := m.checkpointByInode(file, stat); ok {
+		state = fileState{pos: entry.Offset, lines: entry.Lines, counted: entry.Lines > 0}
+		log.Printf("从检查点恢复轮转后的文件 %s (位置: %d)", filePath, state.pos)
+	} else if m.checkpoints != nil && stat.ModTime().After(m.checkpointSavedAt) {
+		state = fileState{}
+		log.Printf("文件 %s 不在检查点中且在上次保存检查点后有写入，从文件开头开始监控", filePath)
+	}
+
+	if id, err := identifyFile(file, stat, fileIdentity{}); err == nil {
+		state.id = id
+	}
+
+	m.mu.Lock()
+	m.files[filePath] = state
+	m.mu.Unlock()
+
+	if rotatedPath != "" && !m.isTrackedFile(rotatedPath) {
+		m.drainRotatedFile(filePath, rotatedPath, rotatedState)
+		m.flushMultiline(filePath)
+		log.Printf("日志文件在停机期间已轮转: %s -> %s", filePath, rotatedPath)
+	}
+}
+
+// checkpointByInode 查找检查点中与文件为同一 inode 的记录（文件在停机期间被重命名）
+func (m *LogMonitor) checkpointByInode(file *os.File, info os.FileInfo) (checkpointEntry, bool) {
+	dev, ino := statDevIno(info)
+	if ino == 0 {
+		return checkpointEntry{}, false
+	}
+	for _, entry := range m.checkpoints {
+		if entry.Inode == ino && entry.Dev == dev && entry.Offset <= info.Size() && entry.sameFile(file, info) {
+			return entry, true
+		}
+	}
+	return checkpointEntry{}, false
+}
+
+// catchUp 读取停机期间写入的内容
+func (m *LogMonitor) catchUp() {
+	m.mu.RLock()
+	var behind []string
+	for filePath, state := range m.files {
+		if stat, err := os.Stat(filePath); err == nil && stat.Size() > state.pos {
+			behind = append(behind, filePath)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, filePath := range behind {
+		m.handleFileWrite(filePath)
+	}
+}
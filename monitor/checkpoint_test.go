@@ -0,0 +1,213 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+	"log-monitor/notifier"
+)
+
+// captureNotifier 记录收到的告警日志内容
+type captureNotifier struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *captureNotifier) Send(a *alert.Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, a.Line)
+	return nil
+}
+
+// received 已收到的告警日志内容
+func (c *captureNotifier) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.lines...)
+}
+
+// newTestMonitor 创建监控 dir 中 .log 文件、命中 ERROR 即告警的监控器，不启动文件监控
+func newTestMonitor(t *testing.T, dir string) (*LogMonitor, *captureNotifier) {
+	cfg := &config.Config{
+		LogDirectories: []config.LogDirectory{{
+			Path:       dir,
+			Keywords:   []config.Match{{Value: "ERROR"}},
+			Extensions: []string{".log"},
+			Enabled:    true,
+		}},
+	}
+	c := &captureNotifier{}
+	m, err := NewLogMonitor(cfg, []notifier.Notifier{c})
+	if err != nil {
+		t.Fatalf("创建监控器失败: %v", err)
+	}
+	t.Cleanup(func() { m.watcher.Close() })
+	m.watchedDirs[dir] = &cfg.LogDirectories[0]
+	return m, c
+}
+
+// writeFile 写入测试文件
+func writeFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+}
+
+// appendFile 向测试文件追加内容
+func appendFile(t *testing.T, path, content string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("打开文件失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("追加内容失败: %v", err)
+	}
+}
+
+// checkpointOf 按文件当前内容构造检查点记录
+func checkpointOf(t *testing.T, path string, offset, lines int64) checkpointEntry {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("获取文件信息失败: %v", err)
+	}
+	id, err := identifyFile(file, info, fileIdentity{})
+	if err != nil {
+		t.Fatalf("计算文件身份失败: %v", err)
+	}
+	return checkpointEntry{Offset: offset, Lines: lines, fileIdentity: id}
+}
+
+// readLines 读取文件新增内容
+func readLines(t *testing.T, m *LogMonitor, path string) []logLine {
+	lines, err := m.readNewLines(path)
+	if err != nil {
+		t.Fatalf("读取新增内容失败: %v", err)
+	}
+	return lines
+}
+
+func TestInitFilePosResumesSameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	writeFile(t, path, "INFO start\nERROR before stop\n")
+
+	m, _ := newTestMonitor(t, dir)
+	m.checkpoints = map[string]checkpointEntry{path: checkpointOf(t, path, int64(len("INFO start\n")), 1)}
+	m.checkpointSavedAt = time.Now()
+
+	appendFile(t, path, "ERROR while down\n")
+	m.initFilePos(path)
+
+	want := []logLine{{2, "ERROR before stop"}, {3, "ERROR while down"}}
+	if got := readLines(t, m, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("恢复后读取到 %v，期望 %v", got, want)
+	}
+}
+
+func TestInitFilePosReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	writeFile(t, path, "INFO old content\n")
+
+	m, _ := newTestMonitor(t, dir)
+	m.checkpoints = map[string]checkpointEntry{path: checkpointOf(t, path, int64(len("INFO old content\n")), 1)}
+	m.checkpointSavedAt = time.Now()
+
+	// 停机期间文件被删除后重新创建
+	os.Remove(path)
+	writeFile(t, path, "ERROR new file\n")
+	m.initFilePos(path)
+
+	want := []logLine{{1, "ERROR new file"}}
+	if got := readLines(t, m, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("文件被替换后读取到 %v，期望从头读取 %v", got, want)
+	}
+}
+
+func TestInitFilePosDrainsRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rotated := filepath.Join(dir, "app.log.1")
+	writeFile(t, path, "ERROR already sent\n")
+
+	m, c := newTestMonitor(t, dir)
+	m.checkpoints = map[string]checkpointEntry{path: checkpointOf(t, path, int64(len("ERROR already sent\n")), 1)}
+	m.checkpointSavedAt = time.Now()
+
+	// 停机期间写入后被轮转
+	appendFile(t, path, "ERROR before rotate\n")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("重命名文件失败: %v", err)
+	}
+	writeFile(t, path, "ERROR after rotate\n")
+
+	m.initFilePos(path)
+	m.delivering.Wait()
+	if got := c.received(); !reflect.DeepEqual(got, []string{"ERROR before rotate"}) {
+		t.Errorf("轮转走的文件发送了 %v，期望只发送检查点之后的内容", got)
+	}
+	if _, tracked := m.files[rotated]; tracked {
+		t.Errorf("不在监控范围内的轮转文件读完后不应继续跟踪")
+	}
+
+	want := []logLine{{1, "ERROR after rotate"}}
+	if got := readLines(t, m, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("新文件读取到 %v，期望从头读取 %v", got, want)
+	}
+}
+
+func TestInitFilePosUnrecordedFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		checkpoint    bool
+		modifiedAfter time.Duration // 文件修改时间相对检查点保存时间
+		wantFromTop   bool
+	}{
+		{"首次启动从末尾开始", false, time.Minute, false},
+		{"检查点之后创建的文件从头读取", true, time.Minute, true},
+		{"检查点之前已存在的文件从末尾开始", true, -time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "new.log")
+			writeFile(t, path, "ERROR first\n")
+
+			m, _ := newTestMonitor(t, dir)
+			savedAt := time.Now().Add(-time.Hour)
+			if tt.checkpoint {
+				m.checkpoints = map[string]checkpointEntry{}
+				m.checkpointSavedAt = savedAt
+			}
+			modTime := savedAt.Add(tt.modifiedAfter)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatalf("修改文件时间失败: %v", err)
+			}
+
+			m.initFilePos(path)
+			appendFile(t, path, "ERROR second\n")
+
+			want := []logLine{{2, "ERROR second"}}
+			if tt.wantFromTop {
+				want = []logLine{{1, "ERROR first"}, {2, "ERROR second"}}
+			}
+			if got := readLines(t, m, path); !reflect.DeepEqual(got, want) {
+				t.Errorf("读取到 %v，期望 %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+)
+
+// defaultDedupWindow 默认去重窗口
+const defaultDedupWindow = 5 * time.Minute
+
+// 指纹计算前需要屏蔽的易变内容，按顺序替换
+var normalizeRules = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\d{4}[-/]\d{1,2}[-/]\d{1,2}[T ]\d{1,2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`), "<ts>"},
+	{regexp.MustCompile(`\d{4}[-/]\d{1,2}[-/]\d{1,2}`), "<date>"},
+	{regexp.MustCompile(`\d{1,2}:\d{2}:\d{2}(?:[.,]\d+)?`), "<time>"},
+	{regexp.MustCompile(`0[xX][0-9a-fA-F]+`), "<hex>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(?:\.\d+)?`), "<n>"},
+}
+
+// normalizeLine 屏蔽行中的数字、UUID、时间戳等易变内容
+func normalizeLine(line string) string {
+	for _, rule := range normalizeRules {
+		line = rule.re.ReplaceAllString(line, rule.replacement)
+	}
+	return line
+}
+
+// deduplicator 告警去重器
+// 窗口内同一指纹的告警只发送第一条，窗口结束时如有重复则发送汇总并开启新窗口
+type deduplicator struct {
+	window    time.Duration
+	summarize func(latest *alert.Alert, count int, window time.Duration) // 重复汇总回调
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry // 按指纹索引的窗口状态
+}
+
+// dedupEntry 单个指纹的窗口状态
+type dedupEntry struct {
+	latest *alert.Alert // 最近一次被抑制的告警
+	count  int          // 窗口内被抑制的次数
+	timer  *time.Timer  // 窗口结束定时器
+}
+
+// newDeduplicator 创建告警去重器
+func newDeduplicator(window time.Duration) *deduplicator {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	return &deduplicator{
+		window:  window,
+		entries: make(map[string]*dedupEntry),
+	}
+}
+
+// allow 判断告警是否需要立即发送，窗口内的重复告警返回 false 并计数
+func (d *deduplicator) allow(a *alert.Alert) bool {
+	key := fingerprint(a.File, a.Line)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, exists := d.entries[key]; exists {
+		entry.count++
+		entry.latest = a
+		return false
+	}
+
+	entry := &dedupEntry{latest: a}
+	entry.timer = time.AfterFunc(d.window, func() { d.closeWindow(key, entry) })
+	d.entries[key] = entry
+	return true
+}
+
+// closeWindow 窗口结束：有重复则发送汇总并开启新窗口，否则清除状态
+func (d *deduplicator) closeWindow(key string, entry *dedupEntry) {
+	d.mu.Lock()
+	if d.entries[key] != entry {
+		d.mu.Unlock()
+		return
+	}
+	count, latest := entry.count, entry.latest
+	if count == 0 {
+		delete(d.entries, key)
+	} else {
+		// 重复仍在持续，下一个窗口继续只发汇总
+		entry.count = 0
+		entry.timer.Reset(d.window)
+	}
+	d.mu.Unlock()
+
+	if count > 0 {
+		d.summarize(latest, count, d.window)
+	}
+}
+
+// stop 停止所有窗口定时器，窗口内有重复的告警立即发送汇总，避免退出时丢失重复次数
+func (d *deduplicator) stop() {
+	d.mu.Lock()
+	var pending []*dedupEntry
+	for key, entry := range d.entries {
+		entry.timer.Stop()
+		delete(d.entries, key)
+		if entry.count > 0 {
+			pending = append(pending, entry)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, entry := range pending {
+		d.summarize(entry.latest, entry.count, d.window)
+	}
+}
+
+// fingerprint 计算告警指纹
+func fingerprint(filePath, line string) string {
+	h := sha256.Sum256([]byte(filePath + "\x00" + normalizeLine(line)))
+	return hex.EncodeToString(h[:])
+}
+
+// formatWindow 格式化时间窗口
+func formatWindow(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d 小时", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%d 分钟", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%d 秒", d/time.Second)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"log-monitor/alert"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"ERROR connection refused", "ERROR connection refused"},
+		{"2024-01-15 14:30:25 ERROR timeout", "<ts> ERROR timeout"},
+		{"2024-01-15T14:30:25.123+08:00 ERROR timeout", "<ts> ERROR timeout"},
+		{"2024/01/15 ERROR timeout", "<date> ERROR timeout"},
+		{"[14:30:25,123] ERROR timeout", "[<time>] ERROR timeout"},
+		{"request 550e8400-e29b-41d4-a716-446655440000 failed", "request <uuid> failed"},
+		{"panic at 0x7ffd5e8a", "panic at <hex>"},
+		{"trace deadbeefcafebabe0123 failed", "trace <hex> failed"},
+		{"user 12345 took 3.5s", "user <n> took <n>s"},
+		{"retry 3/5 after 200ms", "retry <n>/<n> after <n>ms"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeLine(tt.line); got != tt.want {
+			t.Errorf("normalizeLine(%q) = %q，期望 %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFingerprintIgnoresVolatileContent(t *testing.T) {
+	a := fingerprint("/var/log/app.log", "2024-01-15 14:30:25 ERROR user 1001 login failed")
+	b := fingerprint("/var/log/app.log", "2024-01-15 14:31:02 ERROR user 2002 login failed")
+	if a != b {
+		t.Errorf("仅时间和数字不同的行指纹应相同")
+	}
+	if c := fingerprint("/var/log/other.log", "2024-01-15 14:30:25 ERROR user 1001 login failed"); a == c {
+		t.Errorf("不同文件的行指纹应不同")
+	}
+}
+
+func TestDeduplicatorStopSendsPendingSummaries(t *testing.T) {
+	type summary struct {
+		line  string
+		count int
+	}
+	var got []summary
+	d := newDeduplicator(time.Hour)
+	d.summarize = func(latest *alert.Alert, count int, window time.Duration) {
+		got = append(got, summary{latest.Line, count})
+	}
+
+	repeated := &alert.Alert{File: "/var/log/app.log", Line: "ERROR timeout"}
+	once := &alert.Alert{File: "/var/log/app.log", Line: "ERROR refused"}
+	for i := 0; i < 3; i++ {
+		d.allow(repeated)
+	}
+	d.allow(once)
+	d.stop()
+
+	if len(got) != 1 || got[0] != (summary{"ERROR timeout", 2}) {
+		t.Errorf("退出时的汇总 = %v，期望只汇总重复 2 次的告警", got)
+	}
+	if len(d.entries) != 0 {
+		t.Errorf("退出后应清除所有窗口状态")
+	}
+}
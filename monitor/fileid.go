@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// fingerprintSize 计算文件头部指纹使用的最大字节数
+const fingerprintSize = 1024
+
+// fileIdentity 文件身份标识（设备号/inode + 头部内容指纹）
+type fileIdentity struct {
+	Dev             uint64 `json:"dev"`
+	Inode           uint64 `json:"inode"`
+	Fingerprint     string `json:"fingerprint"`      // 文件头部内容的 SHA-256
+	FingerprintSize int64  `json:"fingerprint_size"` // 参与计算指纹的字节数
+}
+
+// identifyFile 计算已打开文件的身份标识，inode 未变且指纹已完整时复用 prev
+func identifyFile(file *os.File, info os.FileInfo, prev fileIdentity) (fileIdentity, error) {
+	dev, ino := statDevIno(info)
+	if prev.Fingerprint != "" && prev.Dev == dev && prev.Inode == ino &&
+		prev.FingerprintSize == fingerprintSize {
+		return prev, nil
+	}
+
+	size := info.Size()
+	if size > fingerprintSize {
+		size = fingerprintSize
+	}
+	fingerprint, err := headFingerprint(file, size)
+	if err != nil {
+		return fileIdentity{}, err
+	}
+
+	return fileIdentity{
+		Dev:             dev,
+		Inode:           ino,
+		Fingerprint:     fingerprint,
+		FingerprintSize: size,
+	}, nil
+}
+
+// headFingerprint 计算文件前 size 字节的指纹
+func headFingerprint(file *os.File, size int64) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, size)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sameFile 判断文件是否与记录的身份一致
+func (id fileIdentity) sameFile(file *os.File, info os.FileInfo) bool {
+	dev, ino := statDevIno(info)
+	if id.Inode != 0 && (id.Dev != dev || id.Inode != ino) {
+		return false
+	}
+	if info.Size() < id.FingerprintSize {
+		return false
+	}
+
+	fingerprint, err := headFingerprint(file, id.FingerprintSize)
+	return err == nil && fingerprint == id.Fingerprint
+}
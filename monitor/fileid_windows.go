@@ -0,0 +1,12 @@
+//go:build windows
+
+package monitor
+
+import (
+	"os"
+)
+
+// statDevIno Windows 下无法从 FileInfo 获取 inode，仅依赖头部指纹识别文件
+func statDevIno(info os.FileInfo) (dev, ino uint64) {
+	return 0, 0
+}
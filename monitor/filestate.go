@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// fileState 单个文件的读取状态
+type fileState struct {
+	pos     int64        // 已读取位置
+	lines   int64        // pos 之前的行数
+	counted bool         // 行数是否已统计（从文件中间开始监控时延迟到首次读取再统计）
+	id      fileIdentity // 文件身份标识
+}
+
+// linesKnown 行数是否可用，从文件开头读取时行数必然为0
+func (s fileState) linesKnown() bool {
+	return s.pos == 0 || s.counted
+}
+
+// logLine 带行号的日志行
+type logLine struct {
+	num  int64
+	text string
+}
+
+// countLines 统计文件前 size 字节中的行数
+func countLines(file *os.File, size int64) (int64, error) {
+	var count int64
+	buf := make([]byte, 32*1024)
+	r := io.NewSectionReader(file, 0, size)
+	for {
+		n, err := r.Read(buf)
+		count += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
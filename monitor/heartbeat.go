@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// heartbeat 静默检测状态：超过 within 没有新日志（或没有匹配 pattern 的日志）时告警
+type heartbeat struct {
+	within  time.Duration
+	pattern *regexp.Regexp // 期望出现的日志，为nil时任意新日志都算活跃
+
+	mu       sync.Mutex
+	lastSeen time.Time // 最近一次活跃时间
+	silent   bool      // 是否已发送静默告警
+}
+
+// newHeartbeat 创建静默检测状态
+func newHeartbeat(within time.Duration, pattern string) (*heartbeat, error) {
+	hb := &heartbeat{within: within, lastSeen: time.Now()}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("编译 expect_pattern 失败: %v", err)
+		}
+		hb.pattern = re
+	}
+	return hb, nil
+}
+
+// observe 记录新读取的日志行，从静默中恢复时返回 true 和静默时长
+func (hb *heartbeat) observe(lines []logLine, now time.Time) (recovered bool, silentFor time.Duration) {
+	active := false
+	for _, line := range lines {
+		if hb.pattern == nil || hb.pattern.MatchString(line.text) {
+			active = true
+			break
+		}
+	}
+	if !active {
+		return false, 0
+	}
+
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	recovered, silentFor = hb.silent, now.Sub(hb.lastSeen)
+	hb.lastSeen = now
+	hb.silent = false
+	return recovered, silentFor
+}
+
+// check 检查是否超时未活跃，每次进入静默只返回一次 true
+func (hb *heartbeat) check(now time.Time) (fire bool, silentFor time.Duration) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	silentFor = now.Sub(hb.lastSeen)
+	if hb.silent || silentFor < hb.within {
+		return false, silentFor
+	}
+	hb.silent = true
+	return true, silentFor
+}
+
+// describe 描述期望的活跃内容
+func (hb *heartbeat) describe() string {
+	if hb.pattern != nil {
+		return fmt.Sprintf("匹配 %s 的日志", hb.pattern.String())
+	}
+	return "新日志写入"
+}
+
+// heartbeatLoop 定期检查各监控源是否静默
+func (m *LogMonitor) heartbeatLoop() {
+	// 检查间隔取最短静默时长的1/10，限制在1秒到1分钟之间
+	interval := time.Minute
+	for _, src := range m.sources {
+		if src.heartbeat != nil && src.heartbeat.within/10 < interval {
+			interval = src.heartbeat.within / 10
+		}
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.checkHeartbeats(now)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// checkHeartbeats 检查各监控源是否静默并发送告警
+func (m *LogMonitor) checkHeartbeats(now time.Time) {
+	for _, src := range m.sources {
+		if src.heartbeat == nil {
+			continue
+		}
+		if fire, silentFor := src.heartbeat.check(now); fire {
+			log.Printf("日志静默: %s 已 %s没有%s", src.path, formatWindow(silentFor.Truncate(time.Second)), src.heartbeat.describe())
+			m.sendSilenceAlert(src)
+		}
+	}
+}
+
+// observeActivity 记录监控源的活跃情况，从静默中恢复时发送恢复通知
+func (m *LogMonitor) observeActivity(src *source, lines []logLine) {
+	if src.heartbeat == nil || len(lines) == 0 {
+		return
+	}
+	if recovered, silentFor := src.heartbeat.observe(lines, time.Now()); recovered {
+		log.Printf("日志恢复: %s (静默 %s)", src.path, formatWindow(silentFor.Truncate(time.Second)))
+		m.sendRecovery(src, silentFor)
+	}
+}
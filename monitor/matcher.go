@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"log-monitor/config"
+)
+
+// matcher 编译后的匹配规则（关键词 + 正则表达式）
+type matcher struct {
+	keywords []string         // 关键词原文
+	lowered  []string         // 小写关键词（匹配不区分大小写）
+	patterns []*regexp.Regexp // 正则表达式（启动时编译一次）
+
+	keywordSeverity []string // 各关键词配置的告警级别，未配置时为空
+	patternSeverity []string // 各正则配置的告警级别，未配置时为空
+}
+
+// matchResult 单行匹配结果
+type matchResult struct {
+	Keyword  string            // 命中的关键词或正则表达式
+	Fields   map[string]string // 正则命名捕获组
+	rule     *rule             // 命中的告警规则
+	severity string            // 命中的关键词或正则配置的告警级别，未配置时为空
+
+	// 阈值规则触发时的统计信息
+	Count   int           // 窗口内命中次数
+	Window  time.Duration // 阈值窗口
+	Samples []string      // 最近的样例行
+}
+
+// newMatcher 编译匹配规则
+func newMatcher(keywords, patterns []string) (*matcher, error) {
+	mt := &matcher{}
+	for _, keyword := range keywords {
+		mt.keywords = append(mt.keywords, keyword)
+		mt.lowered = append(mt.lowered, strings.ToLower(keyword))
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("编译正则规则失败 %s: %v", pattern, err)
+		}
+		mt.patterns = append(mt.patterns, re)
+	}
+	return mt, nil
+}
+
+// newSeverityMatcher 编译可以单独配置告警级别的关键词和正则规则
+func newSeverityMatcher(keywords, patterns []config.Match) (*matcher, error) {
+	var keywordValues, patternValues []string
+	for _, k := range keywords {
+		keywordValues = append(keywordValues, k.Value)
+	}
+	for _, p := range patterns {
+		patternValues = append(patternValues, p.Value)
+	}
+
+	mt, err := newMatcher(keywordValues, patternValues)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keywords {
+		mt.keywordSeverity = append(mt.keywordSeverity, k.Severity)
+	}
+	for _, p := range patterns {
+		mt.patternSeverity = append(mt.patternSeverity, p.Severity)
+	}
+	return mt, nil
+}
+
+// alertSeverity 告警级别：命中的关键词或正则配置的级别优先，其次为所属规则的级别
+func (r *matchResult) alertSeverity() string {
+	if r.severity != "" {
+		return r.severity
+	}
+	return r.rule.severity
+}
+
+// match 检查行是否命中关键词或正则规则
+func (mt *matcher) match(line string) (*matchResult, bool) {
+	lineLower := strings.ToLower(line)
+	for i, keyword := range mt.lowered {
+		if strings.Contains(lineLower, keyword) {
+			result := &matchResult{Keyword: mt.keywords[i]}
+			if i < len(mt.keywordSeverity) {
+				result.severity = mt.keywordSeverity[i]
+			}
+			return result, true
+		}
+	}
+
+	for j, re := range mt.patterns {
+		submatches := re.FindStringSubmatch(line)
+		if submatches == nil {
+			continue
+		}
+
+		result := &matchResult{Keyword: re.String()}
+		if j < len(mt.patternSeverity) {
+			result.severity = mt.patternSeverity[j]
+		}
+		for i, name := range re.SubexpNames() {
+			if name == "" || i >= len(submatches) {
+				continue
+			}
+			if result.Fields == nil {
+				result.Fields = make(map[string]string)
+			}
+			result.Fields[name] = submatches[i]
+		}
+		return result, true
+	}
+
+	return nil, false
+}
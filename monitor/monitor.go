@@ -11,21 +11,36 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"log-monitor/alert"
 	"log-monitor/config"
 	"log-monitor/notifier"
 )
 
+// deliveryShutdownTimeout 退出时等待发送中的告警完成的最长时间
+const deliveryShutdownTimeout = 10 * time.Second
+
 // LogMonitor 日志监控器
 type LogMonitor struct {
-	watcher      *fsnotify.Watcher
-	config       *config.Config
-	notifiers    []notifier.Notifier
-	filePos      map[string]int64 // 记录文件读取位置
-	watchedFiles map[string]*config.LogFile // 监控的文件映射
-	watchedDirs  map[string]*config.LogDirectory // 监控的目录映射
-	mu           sync.RWMutex // 保护并发访问
-	maxFileSize  int64        // 最大文件大小限制 (默认100MB)
-	bufferSize   int          // 读取缓冲区大小 (默认64KB)
+	watcher           *fsnotify.Watcher
+	config            *config.Config
+	notifiers         []notifier.Notifier
+	files             map[string]fileState            // 记录文件读取状态
+	checkpoints       map[string]checkpointEntry      // 启动时加载的检查点
+	checkpointSavedAt time.Time                       // 启动时加载的检查点的保存时间
+	watchedFiles      map[string]*config.LogFile      // 监控的文件映射
+	watchedDirs       map[string]*config.LogDirectory // 监控的目录映射
+	sources           map[string]*source              // 按配置路径索引的监控源规则
+	stats             monitorStats                    // 统计信息
+	dedup             *deduplicator                   // 告警去重器，未配置时为nil
+	silencer          *silencer                       // 告警屏蔽器，未配置时为nil
+	mu                sync.RWMutex                    // 保护并发访问
+	maxFileSize       int64                           // 最大文件大小限制 (默认100MB)
+	bufferSize        int                             // 读取缓冲区大小 (默认64KB)
+	hostname          string                          // 主机名，用于告警消息
+	done              chan struct{}                   // 关闭时通知后台任务退出
+	watching          sync.WaitGroup                  // 运行中的监控循环
+	delivering        sync.WaitGroup                  // 发送中的告警
+	stopOnce          sync.Once
 }
 
 // NewLogMonitor 创建新的日志监控器
@@ -35,27 +50,90 @@ func NewLogMonitor(cfg *config.Config, notifiers []notifier.Notifier) (*LogMonit
 		return nil, fmt.Errorf("创建文件监控器失败: %v", err)
 	}
 
-	return &LogMonitor{
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	m := &LogMonitor{
 		watcher:      watcher,
 		config:       cfg,
 		notifiers:    notifiers,
-		filePos:      make(map[string]int64),
+		files:        make(map[string]fileState),
 		watchedFiles: make(map[string]*config.LogFile),
 		watchedDirs:  make(map[string]*config.LogDirectory),
+		sources:      make(map[string]*source),
 		maxFileSize:  100 * 1024 * 1024, // 100MB
-		bufferSize:   64 * 1024,          // 64KB
-	}, nil
+		bufferSize:   64 * 1024,         // 64KB
+		hostname:     hostname,
+		done:         make(chan struct{}),
+	}
+
+	if cfg.Dedup != nil {
+		m.dedup = newDeduplicator(cfg.Dedup.Window)
+		m.dedup.summarize = m.sendRepeatSummary
+	}
+
+	if len(cfg.Silences) > 0 || cfg.SilenceFile != "" {
+		if m.silencer, err = newSilencer(cfg.Silences, cfg.SilenceFile); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		m.silencer.summarize = m.sendSilenceSummary
+	}
+
+	// 预编译匹配规则
+	for i := range cfg.LogFiles {
+		if !cfg.LogFiles[i].Enabled {
+			continue
+		}
+		src, err := newFileSource(&cfg.LogFiles[i])
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("日志文件 %s %v", cfg.LogFiles[i].Path, err)
+		}
+		m.addSource(src)
+	}
+	for i := range cfg.LogDirectories {
+		if !cfg.LogDirectories[i].Enabled {
+			continue
+		}
+		src, err := newDirSource(&cfg.LogDirectories[i])
+		if err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("日志目录 %s %v", cfg.LogDirectories[i].Path, err)
+		}
+		m.addSource(src)
+	}
+
+	return m, nil
+}
+
+// addSource 注册监控源
+func (m *LogMonitor) addSource(src *source) {
+	if src.multiline != nil {
+		src.multiline.emit = func(filePath string, event logLine) {
+			m.processEvent(src, filePath, event)
+		}
+	}
+	m.sources[src.path] = src
 }
 
 // Start 开始监控
 func (m *LogMonitor) Start() error {
+	// 加载检查点
+	if err := m.loadCheckpoint(); err != nil {
+		log.Printf("加载检查点失败，从文件末尾开始监控: %v", err)
+	}
+
 	// 添加监控文件
-	for _, logFile := range m.config.LogFiles {
+	for i := range m.config.LogFiles {
+		logFile := &m.config.LogFiles[i]
 		if !logFile.Enabled {
 			continue
 		}
 
-		err := m.addFileWatch(logFile.Path, &logFile)
+		err := m.addFileWatch(logFile.Path, logFile)
 		if err != nil {
 			log.Printf("添加监控文件失败 %s: %v", logFile.Path, err)
 			continue
@@ -65,12 +143,13 @@ func (m *LogMonitor) Start() error {
 	}
 
 	// 添加监控目录
-	for _, logDir := range m.config.LogDirectories {
+	for i := range m.config.LogDirectories {
+		logDir := &m.config.LogDirectories[i]
 		if !logDir.Enabled {
 			continue
 		}
 
-		err := m.addDirectoryWatch(&logDir)
+		err := m.addDirectoryWatch(logDir)
 		if err != nil {
 			log.Printf("添加监控目录失败 %s: %v", logDir.Path, err)
 			continue
@@ -79,38 +158,107 @@ func (m *LogMonitor) Start() error {
 		log.Printf("开始监控目录: %s (递归: %v)", logDir.Path, logDir.Recursive)
 	}
 
+	// 处理停机期间写入的内容
+	m.catchUp()
+
 	// 启动监控循环
+	m.watching.Add(1)
 	go m.watchLoop()
 
 	// 启动定期清理任务
 	go m.cleanupLoop()
 
+	// 启动检查点保存任务
+	if m.config.Checkpoint != nil {
+		go m.checkpointLoop()
+	}
+
+	// 启动告警屏蔽检查任务
+	if m.silencer != nil {
+		go m.silenceLoop()
+	}
+
+	// 启动静默检测任务
+	for _, src := range m.sources {
+		if src.heartbeat != nil {
+			go m.heartbeatLoop()
+			break
+		}
+	}
+
 	return nil
 }
 
 // Stop 停止监控
 func (m *LogMonitor) Stop() error {
-	return m.watcher.Close()
+	m.stopOnce.Do(func() { close(m.done) })
+	err := m.watcher.Close()
+	m.watching.Wait()
+
+	// 先输出未完成的多行事件再保存检查点，否则检查点已越过这些行，事件却没有发送
+	for _, src := range m.sources {
+		if src.multiline != nil {
+			src.multiline.stop()
+		}
+	}
+	if m.dedup != nil {
+		m.dedup.stop()
+	}
+
+	// 等待发送中的告警完成，超时后停止重试，正在等待重试的告警写入死信队列
+	if !m.waitDelivering(deliveryShutdownTimeout) {
+		log.Printf("仍有告警在发送中，停止重试并写入死信队列")
+	}
+	for _, n := range m.notifiers {
+		notifier.Shutdown(n)
+	}
+	// 正在执行的请求和命令不能中断，只等待其超时，之后不再等待
+	if !m.waitDelivering(deliveryShutdownTimeout) {
+		log.Printf("等待告警发送超时，未完成的告警已放弃")
+	}
+
+	if err := m.saveCheckpoint(); err != nil {
+		log.Printf("保存检查点失败: %v", err)
+	}
+	return err
+}
+
+// waitDelivering 等待发送中的告警完成，超时返回false
+func (m *LogMonitor) waitDelivering(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		m.delivering.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
 }
 
 // addFileWatch 添加文件监控
+// 监控文件所在目录而不是文件本身，文件被轮转（重命名或删除后重建）后仍能收到新文件的事件
 func (m *LogMonitor) addFileWatch(filePath string, logFile *config.LogFile) error {
-	err := m.watcher.Add(filePath)
+	filePath = filepath.Clean(filePath)
+	err := m.watcher.Add(filepath.Dir(filePath))
 	if err != nil {
 		return err
 	}
 
-	// 初始化文件位置
-	if stat, err := os.Stat(filePath); err == nil {
-		m.mu.Lock()
-		m.filePos[filePath] = stat.Size()
-		m.mu.Unlock()
-	}
-
 	// 记录监控的文件
 	m.mu.Lock()
 	m.watchedFiles[filePath] = logFile
 	m.mu.Unlock()
+
+	// 初始化文件位置
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		log.Printf("日志文件 %s 暂不存在，创建后自动开始监控", filePath)
+		return nil
+	}
+	m.initFilePos(filePath)
 	return nil
 }
 
@@ -118,7 +266,7 @@ func (m *LogMonitor) addFileWatch(filePath string, logFile *config.LogFile) erro
 func (m *LogMonitor) addDirectoryWatch(logDir *config.LogDirectory) error {
 	// 记录监控的目录
 	m.mu.Lock()
-	m.watchedDirs[logDir.Path] = logDir
+	m.watchedDirs[filepath.Clean(logDir.Path)] = logDir
 	m.mu.Unlock()
 
 	if logDir.Recursive {
@@ -179,7 +327,7 @@ func (m *LogMonitor) scanExistingFiles(dirPath string, logDir *config.LogDirecto
 		}
 
 		filePath := filepath.Join(dirPath, entry.Name())
-		
+
 		// 检查文件扩展名
 		if !m.matchesExtensions(filePath, logDir.Extensions) {
 			continue
@@ -188,21 +336,21 @@ func (m *LogMonitor) scanExistingFiles(dirPath string, logDir *config.LogDirecto
 		// 检查文件大小限制
 		if stat, err := os.Stat(filePath); err == nil {
 			if stat.Size() > m.maxFileSize {
-				log.Printf("跳过大文件 %s (大小: %d bytes, 限制: %d bytes)", 
+				log.Printf("跳过大文件 %s (大小: %d bytes, 限制: %d bytes)",
 					filePath, stat.Size(), m.maxFileSize)
 				continue
 			}
 
 			// 初始化文件位置
-			m.mu.Lock()
 			if isNewDir {
 				// 新目录，从文件末尾开始监控
-				m.filePos[filePath] = stat.Size()
+				m.mu.Lock()
+				m.files[filePath] = fileState{pos: stat.Size()}
+				m.mu.Unlock()
 			} else {
-				// 现有目录，从文件末尾开始监控（避免重复处理历史日志）
-				m.filePos[filePath] = stat.Size()
+				// 现有目录，有检查点时从检查点继续，否则从文件末尾开始
+				m.initFilePos(filePath)
 			}
-			m.mu.Unlock()
 		}
 	}
 
@@ -229,8 +377,11 @@ func (m *LogMonitor) matchesExtensions(filePath string, extensions []string) boo
 	}
 	return false
 }
+
 // watchLoop 监控循环
 func (m *LogMonitor) watchLoop() {
+	defer m.watching.Done()
+
 	for {
 		select {
 		case event, ok := <-m.watcher.Events:
@@ -251,62 +402,127 @@ func (m *LogMonitor) watchLoop() {
 
 // handleEvent 处理文件系统事件
 func (m *LogMonitor) handleEvent(event fsnotify.Event) {
+	// 统一路径格式，与 watchedFiles/watchedDirs 中记录的路径保持一致
+	name := filepath.Clean(event.Name)
+
 	switch {
 	case event.Op&fsnotify.Write == fsnotify.Write:
-		m.handleFileWrite(event.Name)
+		m.handleFileWrite(name)
 	case event.Op&fsnotify.Create == fsnotify.Create:
-		m.handleFileCreate(event.Name)
+		m.handleFileCreate(name)
 	case event.Op&fsnotify.Remove == fsnotify.Remove:
-		m.handleFileRemove(event.Name)
+		m.handleFileRemove(name)
 	case event.Op&fsnotify.Rename == fsnotify.Rename:
-		m.handleFileRename(event.Name)
+		m.handleFileRename(name)
 	}
 }
 
 // handleFileCreate 处理文件创建事件
 func (m *LogMonitor) handleFileCreate(filePath string) {
-	// 检查是否是目录中的新文件
+	// 轮转到监控范围内新名称的文件，继续沿用原读取位置
+	if m.isTrackedFile(filePath) {
+		return
+	}
+
 	m.mu.RLock()
-	for watchedDir, logDir := range m.watchedDirs {
-		if m.isFileInDirectory(filePath, watchedDir, logDir.Recursive) {
-			// 检查文件扩展名
-			if m.matchesExtensions(filePath, logDir.Extensions) {
-				// 检查文件大小限制
-				if stat, err := os.Stat(filePath); err == nil && stat.Size() <= m.maxFileSize {
-					// 初始化文件位置（新文件从头开始）
-					m.mu.RUnlock()
-					m.mu.Lock()
-					m.filePos[filePath] = 0
-					m.mu.Unlock()
-					log.Printf("检测到新日志文件: %s", filePath)
-				} else if err == nil {
-					log.Printf("跳过大文件 %s (大小: %d bytes)", filePath, stat.Size())
-				}
-			}
-			break
+	_, direct := m.watchedFiles[filePath]
+	m.mu.RUnlock()
+
+	if !direct {
+		// 检查是否是目录中的新文件
+		logDir := m.findDirectory(filePath)
+		if logDir == nil {
+			return
 		}
 	}
-	if len(m.watchedDirs) > 0 {
-		m.mu.RUnlock()
+
+	// 检查文件大小限制
+	stat, err := os.Stat(filePath)
+	if err != nil || stat.IsDir() {
+		return
+	}
+	if stat.Size() > m.maxFileSize {
+		log.Printf("跳过大文件 %s (大小: %d bytes)", filePath, stat.Size())
+		return
+	}
+
+	// 初始化文件位置（新文件从头开始）
+	m.mu.Lock()
+	m.files[filePath] = fileState{}
+	m.mu.Unlock()
+
+	if direct {
+		log.Printf("日志文件已重新创建，重新关联: %s", filePath)
+	} else {
+		log.Printf("检测到新日志文件: %s", filePath)
 	}
+
+	// 文件可能是重命名移入的，创建时已有内容
+	m.handleFileWrite(filePath)
 }
 
 // handleFileRemove 处理文件删除事件
 func (m *LogMonitor) handleFileRemove(filePath string) {
+	if m.isTrackedFile(filePath) {
+		// 事件处理滞后，路径上已是重新创建并跟踪中的新文件
+		return
+	}
+	m.flushMultiline(filePath)
+
 	// 清理文件位置记录
 	m.mu.Lock()
-	delete(m.filePos, filePath)
+	_, tracked := m.files[filePath]
+	_, direct := m.watchedFiles[filePath]
+	delete(m.files, filePath)
 	m.mu.Unlock()
-	log.Printf("日志文件已删除: %s", filePath)
+
+	if !tracked {
+		return
+	}
+	if direct {
+		log.Printf("日志文件已删除，等待重新创建: %s", filePath)
+	} else {
+		log.Printf("日志文件已删除: %s", filePath)
+	}
 }
 
 // handleFileRename 处理文件重命名事件
 func (m *LogMonitor) handleFileRename(filePath string) {
+	if m.isTrackedFile(filePath) {
+		// 事件处理滞后，路径上已是重新创建并跟踪中的新文件
+		return
+	}
+
 	// 清理旧文件位置记录
 	m.mu.Lock()
-	delete(m.filePos, filePath)
+	state, tracked := m.files[filePath]
+	_, direct := m.watchedFiles[filePath]
+	delete(m.files, filePath)
 	m.mu.Unlock()
-	log.Printf("日志文件已重命名: %s", filePath)
+
+	if !tracked {
+		return
+	}
+
+	// 按 inode 找到轮转后的文件，读完轮转前尚未处理的内容
+	if rotatedPath := m.findRotatedFile(filepath.Dir(filePath), state.id); rotatedPath != "" {
+		m.drainRotatedFile(filePath, rotatedPath, state)
+		log.Printf("日志文件已轮转: %s -> %s", filePath, rotatedPath)
+	} else {
+		log.Printf("日志文件已重命名: %s", filePath)
+	}
+	m.flushMultiline(filePath)
+
+	if direct {
+		log.Printf("等待日志文件重新创建: %s", filePath)
+	}
+}
+
+// flushMultiline 输出文件未完成的多行事件
+func (m *LogMonitor) flushMultiline(filePath string) {
+	if src := m.findSource(filePath); src != nil && src.multiline != nil {
+		src.multiline.flush(filePath)
+	}
 }
 
 // isFileInDirectory 检查文件是否在监控目录中
@@ -320,48 +536,100 @@ func (m *LogMonitor) isFileInDirectory(filePath, dirPath string, recursive bool)
 
 // handleFileWrite 处理文件写入事件
 func (m *LogMonitor) handleFileWrite(filePath string) {
-	// 查找对应的日志文件配置
-	var keywords []string
-	
-	// 检查是否是直接监控的文件
-	m.mu.RLock()
-	if logFile, exists := m.watchedFiles[filePath]; exists {
-		keywords = logFile.Keywords
-	} else {
-		// 检查是否是目录监控中的文件
-		for watchedDir, logDir := range m.watchedDirs {
-			if m.isFileInDirectory(filePath, watchedDir, logDir.Recursive) {
-				// 检查文件扩展名
-				if m.matchesExtensions(filePath, logDir.Extensions) {
-					keywords = logDir.Keywords
-					break
-				}
-			}
-		}
-	}
-	m.mu.RUnlock()
-
-	if len(keywords) == 0 {
+	src := m.findSource(filePath)
+	if src == nil {
 		return
 	}
 
+	m.drainIfReplaced(filePath)
+	m.processNewLines(src, filePath, filePath)
+}
+
+// processNewLines 读取 readPath 的新增内容，按监控源规则以 filePath 的名义匹配告警
+func (m *LogMonitor) processNewLines(src *source, filePath, readPath string) {
 	// 读取新增内容
-	newLines, err := m.readNewLines(filePath)
+	newLines, err := m.readNewLines(readPath)
 	if err != nil {
-		log.Printf("读取文件新内容失败 %s: %v", filePath, err)
+		log.Printf("读取文件新内容失败 %s: %v", readPath, err)
+		return
+	}
+
+	m.stats.linesRead.Add(int64(len(newLines)))
+	m.observeActivity(src, newLines)
+
+	// 多行模式下先合并为完整事件再匹配
+	if src.multiline != nil {
+		src.multiline.feed(filePath, newLines)
 		return
 	}
 
-	// 检查关键词
 	for _, line := range newLines {
-		if m.containsKeywords(line, keywords) {
-			m.sendAlert(filePath, line)
+		m.processEvent(src, filePath, line)
+	}
+}
+
+// processEvent 检查事件是否命中关键词和正则规则
+func (m *LogMonitor) processEvent(src *source, filePath string, event logLine) {
+	result, excluded := src.match(event.text)
+	if excluded {
+		m.stats.excluded.Add(1)
+		return
+	}
+	if result == nil {
+		return
+	}
+	m.stats.matched.Add(1)
+
+	// 阈值规则：窗口内命中次数达到阈值才告警
+	if threshold := result.rule.threshold; threshold != nil {
+		fired, count, samples := threshold.add(filePath, event.text, time.Now())
+		if !fired {
+			return
 		}
+		result.Count, result.Window, result.Samples = count, threshold.window, samples
 	}
+
+	m.sendAlert(filePath, event, result)
+}
+
+// findSource 查找文件对应的监控源
+func (m *LogMonitor) findSource(filePath string) *source {
+	m.mu.RLock()
+	logFile, exists := m.watchedFiles[filePath]
+	m.mu.RUnlock()
+
+	// 检查是否是直接监控的文件
+	if exists {
+		return m.sources[logFile.Path]
+	}
+
+	// 检查是否是目录监控中的文件
+	if logDir := m.findDirectory(filePath); logDir != nil {
+		return m.sources[logDir.Path]
+	}
+
+	return nil
+}
+
+// findDirectory 查找文件所属的监控目录配置
+func (m *LogMonitor) findDirectory(filePath string) *config.LogDirectory {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for watchedDir, logDir := range m.watchedDirs {
+		if m.isFileInDirectory(filePath, watchedDir, logDir.Recursive) {
+			// 检查文件扩展名
+			if m.matchesExtensions(filePath, logDir.Extensions) {
+				return logDir
+			}
+		}
+	}
+
+	return nil
 }
 
 // readNewLines 读取文件新增行
-func (m *LogMonitor) readNewLines(filePath string) ([]string, error) {
+func (m *LogMonitor) readNewLines(filePath string) ([]logLine, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
@@ -375,7 +643,7 @@ func (m *LogMonitor) readNewLines(filePath string) ([]string, error) {
 	}
 
 	currentSize := stat.Size()
-	
+
 	// 检查文件大小限制
 	if currentSize > m.maxFileSize {
 		log.Printf("文件 %s 超过大小限制，跳过读取", filePath)
@@ -383,63 +651,230 @@ func (m *LogMonitor) readNewLines(filePath string) ([]string, error) {
 	}
 
 	m.mu.RLock()
-	lastPos := m.filePos[filePath]
+	state := m.files[filePath]
 	m.mu.RUnlock()
 
-	// 如果文件被截断或重新创建
-	if currentSize < lastPos {
-		lastPos = 0
+	// 如果文件被截断或重新创建（包括 copytruncate 轮转后已写入超过原位置的情况）
+	if state.pos > 0 && isReplaced(file, stat, state.pos, state.id) {
+		log.Printf("检测到文件 %s 被截断或替换，从头开始读取", filePath)
+		state = fileState{}
+	}
+
+	// 从文件中间开始监控时，首次读取前统计已有行数
+	if !state.linesKnown() {
+		if state.lines, err = countLines(file, state.pos); err != nil {
+			return nil, err
+		}
 	}
 
 	// 定位到上次读取位置
-	_, err = file.Seek(lastPos, 0)
+	_, err = file.Seek(state.pos, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	var lines []string
+	var lines []logLine
 	scanner := bufio.NewScanner(file)
-	
+
 	// 设置缓冲区大小
 	buf := make([]byte, 0, m.bufferSize)
 	scanner.Buffer(buf, m.bufferSize)
-	
+
+	lineNum := state.lines
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+		lineNum++
+		lines = append(lines, logLine{num: lineNum, text: scanner.Text()})
 	}
 
-	// 更新文件位置
+	// 更新文件位置、行数和身份标识
+	next := fileState{pos: currentSize, lines: lineNum, counted: true, id: state.id}
+	if id, err := identifyFile(file, stat, state.id); err == nil {
+		next.id = id
+	}
 	m.mu.Lock()
-	m.filePos[filePath] = currentSize
+	m.files[filePath] = next
 	m.mu.Unlock()
 
 	return lines, scanner.Err()
 }
 
-// containsKeywords 检查行是否包含关键词
-func (m *LogMonitor) containsKeywords(line string, keywords []string) bool {
-	lineLower := strings.ToLower(line)
-	for _, keyword := range keywords {
-		if strings.Contains(lineLower, strings.ToLower(keyword)) {
+// sendAlert 发送告警
+func (m *LogMonitor) sendAlert(filePath string, line logLine, result *matchResult) {
+	a := &alert.Alert{
+		Kind:       alert.KindMatch,
+		Title:      "🚨 日志告警",
+		File:       filePath,
+		Hostname:   m.hostname,
+		Rule:       result.rule.name,
+		Severity:   result.alertSeverity(),
+		Keyword:    result.Keyword,
+		Line:       line.text,
+		LineNumber: line.num,
+		Fields:     result.Fields,
+		Time:       time.Now(),
+		Mention:    result.rule.mention,
+		Notifiers:  result.rule.notifiers,
+	}
+	if result.Count > 0 {
+		a.Summary = fmt.Sprintf("最近 %s内命中 %d 次", formatWindow(result.Window), result.Count)
+		a.Samples = result.Samples
+	}
+
+	// 去重窗口内的重复告警只计数，窗口结束时发送汇总
+	if m.dedup != nil && !m.dedup.allow(a) {
+		m.stats.suppressed.Add(1)
+		return
+	}
+
+	m.dispatch(a)
+}
+
+// sendRepeatSummary 发送重复告警汇总，latest 为窗口内最近一次被抑制的告警
+func (m *LogMonitor) sendRepeatSummary(latest *alert.Alert, count int, window time.Duration) {
+	a := *latest
+	a.Kind = alert.KindRepeat
+	a.Title = "🔁 告警重复汇总"
+	a.Time = time.Now()
+	a.Summary = fmt.Sprintf("最近 %s内重复 %d 次", formatWindow(window), count)
+
+	m.dispatch(&a)
+}
+
+// sendSilenceAlert 发送日志静默告警
+func (m *LogMonitor) sendSilenceAlert(src *source) {
+	m.dispatch(&alert.Alert{
+		Kind:      alert.KindSilence,
+		Title:     "🔕 日志静默告警",
+		File:      src.path,
+		Hostname:  m.hostname,
+		Severity:  src.severity,
+		Line:      fmt.Sprintf("已超过 %s没有%s", formatWindow(src.heartbeat.within), src.heartbeat.describe()),
+		Time:      time.Now(),
+		Notifiers: src.notifiers,
+	})
+}
+
+// sendRecovery 发送日志恢复通知
+func (m *LogMonitor) sendRecovery(src *source, silentFor time.Duration) {
+	m.dispatch(&alert.Alert{
+		Kind:      alert.KindRecovery,
+		Title:     "✅ 日志恢复",
+		File:      src.path,
+		Hostname:  m.hostname,
+		Severity:  src.severity,
+		Line:      fmt.Sprintf("已恢复%s，静默时长 %s", src.heartbeat.describe(), formatWindow(silentFor.Truncate(time.Second))),
+		Time:      time.Now(),
+		Notifiers: src.notifiers,
+	})
+}
+
+// dispatch 将告警发送到路由的通知器，告警屏蔽窗口内的通知器只计数不发送，记录类通知器不受屏蔽影响
+func (m *LogMonitor) dispatch(a *alert.Alert) {
+	notifiers := m.route(a)
+	var silenced []string
+	if m.silencer != nil {
+		notifiers, silenced = m.silencer.filter(a, notifiers, time.Now())
+		if len(silenced) > 0 {
+			m.stats.silenced.Add(1)
+		}
+	}
+
+	var recorders []notifier.Recorder
+	var senders []notifier.Notifier
+	for _, n := range notifiers {
+		if r, ok := n.(notifier.Recorder); ok {
+			recorders = append(recorders, r)
+		} else {
+			senders = append(senders, n)
+		}
+	}
+	if len(senders) == 0 && len(recorders) == 0 {
+		return
+	}
+
+	// 被屏蔽的通知器在投递结果中标记为已屏蔽
+	var results []notifier.DeliveryResult
+	for _, name := range silenced {
+		results = append(results, notifier.DeliveryResult{Notifier: name, Silenced: true})
+	}
+
+	if len(senders) > 0 || len(silenced) == 0 {
+		m.stats.alertsSent.Add(1)
+	}
+	// 记录和发送都在独立的 goroutine 中进行，写入告警记录文件（每条 fsync）不阻塞文件监控循环
+	m.delivering.Add(1)
+	go m.deliver(notifier.NewRecordID(), a, senders, recorders, results)
+}
+
+// route 告警路由的通知器：告警配置的通知器，未配置时为默认路由，默认路由未配置时为所有通知器，
+// 并过滤掉告警级别低于最低级别的通知器
+func (m *LogMonitor) route(a *alert.Alert) []notifier.Notifier {
+	names := a.Notifiers
+	if len(names) == 0 {
+		names = m.config.DefaultNotifiers
+	}
+
+	var routed []notifier.Notifier
+	for _, n := range m.notifiers {
+		if len(names) > 0 && !containsName(names, notifier.NameOf(n)) {
+			continue
+		}
+		if !notifier.Accepts(n, a) {
+			continue
+		}
+		routed = append(routed, n)
+	}
+	return routed
+}
+
+// containsName 名称列表中是否包含指定名称
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
 			return true
 		}
 	}
 	return false
 }
 
-// sendAlert 发送告警
-func (m *LogMonitor) sendAlert(filePath, line string) {
-	message := fmt.Sprintf("🚨 日志告警\n\n文件: %s\n时间: %s\n内容: %s",
-		filePath,
-		time.Now().Format("2006-01-02 15:04:05"),
-		line)
+// deliver 记录类通知器先写入告警，再并发发送告警，全部发送完成（包括重试）后追加投递结果，silenced 为被屏蔽通知器的投递结果
+func (m *LogMonitor) deliver(id string, a *alert.Alert, senders []notifier.Notifier, recorders []notifier.Recorder, silenced []notifier.DeliveryResult) {
+	defer m.delivering.Done()
 
-	for _, n := range m.notifiers {
-		go func(notifier notifier.Notifier) {
-			if err := notifier.Send(message); err != nil {
+	for _, r := range recorders {
+		if err := r.Record(id, a); err != nil {
+			log.Printf("记录告警失败: %v", err)
+		}
+	}
+	if len(senders) == 0 && len(silenced) == 0 {
+		return
+	}
+
+	results := make([]notifier.DeliveryResult, len(senders))
+	var wg sync.WaitGroup
+	for i, n := range senders {
+		wg.Add(1)
+		go func(i int, n notifier.Notifier) {
+			defer wg.Done()
+			results[i] = notifier.DeliveryResult{Notifier: notifier.NameOf(n), OK: true}
+			if err := n.Send(a); err != nil {
 				log.Printf("发送通知失败: %v", err)
+				results[i].OK = false
+				results[i].Error = err.Error()
 			}
-		}(n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	m.recordDeliveries(id, a, recorders, append(results, silenced...))
+}
+
+// recordDeliveries 将投递结果交给记录类通知器
+func (m *LogMonitor) recordDeliveries(id string, a *alert.Alert, recorders []notifier.Recorder, results []notifier.DeliveryResult) {
+	for _, r := range recorders {
+		if err := r.RecordDeliveries(id, a, results); err != nil {
+			log.Printf("记录告警失败: %v", err)
+		}
 	}
 }
 
@@ -452,6 +887,8 @@ func (m *LogMonitor) cleanupLoop() {
 		select {
 		case <-ticker.C:
 			m.performCleanup()
+		case <-m.done:
+			return
 		}
 	}
 }
@@ -462,23 +899,37 @@ func (m *LogMonitor) performCleanup() {
 	defer m.mu.Unlock()
 
 	// 清理不存在的文件记录
-	for filePath := range m.filePos {
+	for filePath := range m.files {
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			delete(m.filePos, filePath)
+			delete(m.files, filePath)
 			log.Printf("清理不存在的文件记录: %s", filePath)
 		}
 	}
 
 	// 检查文件大小变化，重置过大文件的位置
-	for filePath, pos := range m.filePos {
+	for filePath, state := range m.files {
 		if stat, err := os.Stat(filePath); err == nil {
-			if stat.Size() > m.maxFileSize && pos < stat.Size() {
+			if stat.Size() > m.maxFileSize && state.pos < stat.Size() {
 				// 文件变得过大，从末尾开始监控
-				m.filePos[filePath] = stat.Size()
+				m.files[filePath] = fileState{pos: stat.Size(), id: state.id}
 				log.Printf("重置大文件位置: %s (大小: %d bytes)", filePath, stat.Size())
 			}
 		}
 	}
 
-	log.Printf("内存清理完成，当前监控文件数: %d", len(m.filePos))
-}
\ No newline at end of file
+	// 清理过期的阈值计数
+	now := time.Now()
+	for _, src := range m.sources {
+		for _, r := range src.rules {
+			if r.threshold != nil {
+				r.threshold.prune(now)
+			}
+		}
+	}
+
+	log.Printf("内存清理完成，当前监控文件数: %d", len(m.files))
+
+	stats := m.stats.snapshot()
+	log.Printf("监控统计: 读取 %d 行，命中 %d 行，排除 %d 行，去重抑制 %d 次，屏蔽 %d 次，告警 %d 次",
+		stats.LinesRead, stats.Matched, stats.Excluded, stats.Suppressed, stats.Silenced, stats.AlertsSent)
+}
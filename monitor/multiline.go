@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"log-monitor/config"
+)
+
+const (
+	defaultMultilineMaxLines     = 500             // 默认单个事件最大行数
+	defaultMultilineFlushTimeout = 2 * time.Second // 默认等待续行的超时时间
+)
+
+// multilineAssembler 多行事件合并器，按文件维护未完成的事件
+type multilineAssembler struct {
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	maxLines     int
+	flushTimeout time.Duration
+	emit         func(filePath string, event logLine) // 事件完成回调，行号为事件第一行的行号
+
+	mu      sync.Mutex
+	pending map[string]*pendingEvent // 按文件路径索引的未完成事件
+}
+
+// pendingEvent 未完成的多行事件
+type pendingEvent struct {
+	firstLine int64 // 第一行的行号
+	lines     []string
+	truncated int         // 超过最大行数被丢弃的行数
+	timer     *time.Timer // 超时刷新定时器
+}
+
+// newMultilineAssembler 创建多行事件合并器
+func newMultilineAssembler(cfg *config.Multiline) (*multilineAssembler, error) {
+	a := &multilineAssembler{
+		maxLines:     cfg.MaxLines,
+		flushTimeout: cfg.FlushTimeout,
+		pending:      make(map[string]*pendingEvent),
+	}
+	if a.maxLines <= 0 {
+		a.maxLines = defaultMultilineMaxLines
+	}
+	if a.flushTimeout <= 0 {
+		a.flushTimeout = defaultMultilineFlushTimeout
+	}
+
+	var err error
+	if cfg.StartPattern != "" {
+		if a.start, err = regexp.Compile(cfg.StartPattern); err != nil {
+			return nil, fmt.Errorf("编译多行起始规则失败: %v", err)
+		}
+	}
+	if cfg.ContinuationPattern != "" {
+		if a.continuation, err = regexp.Compile(cfg.ContinuationPattern); err != nil {
+			return nil, fmt.Errorf("编译多行续行规则失败: %v", err)
+		}
+	}
+	return a, nil
+}
+
+// feed 输入新读取的行，完成的事件通过 emit 回调输出
+func (a *multilineAssembler) feed(filePath string, lines []logLine) {
+	var completed []logLine
+
+	a.mu.Lock()
+	ev := a.pending[filePath]
+	for _, line := range lines {
+		if ev != nil && a.isContinuation(line.text) {
+			if len(ev.lines) < a.maxLines {
+				ev.lines = append(ev.lines, line.text)
+			} else {
+				ev.truncated++
+			}
+			continue
+		}
+
+		// 新事件开始，输出上一个事件
+		if ev != nil {
+			ev.timer.Stop()
+			completed = append(completed, ev.event())
+		}
+		ev = a.newEvent(filePath, line)
+	}
+	if ev != nil {
+		// 有新内容时重新计时
+		ev.timer.Reset(a.flushTimeout)
+	}
+	a.mu.Unlock()
+
+	for _, event := range completed {
+		a.emit(filePath, event)
+	}
+}
+
+// newEvent 创建新的未完成事件并启动超时刷新
+func (a *multilineAssembler) newEvent(filePath string, first logLine) *pendingEvent {
+	ev := &pendingEvent{firstLine: first.num, lines: []string{first.text}}
+	ev.timer = time.AfterFunc(a.flushTimeout, func() {
+		a.mu.Lock()
+		current := a.pending[filePath] == ev
+		if current {
+			delete(a.pending, filePath)
+		}
+		a.mu.Unlock()
+
+		// 事件已被新事件替换时由 feed 负责输出
+		if current {
+			a.emit(filePath, ev.event())
+		}
+	})
+	a.pending[filePath] = ev
+	return ev
+}
+
+// isContinuation 判断行是否属于当前事件
+func (a *multilineAssembler) isContinuation(line string) bool {
+	if a.start != nil && a.start.MatchString(line) {
+		return false
+	}
+	if a.continuation != nil {
+		return a.continuation.MatchString(line)
+	}
+	// 只配置了起始规则，不匹配起始规则的行都是续行
+	return true
+}
+
+// flush 输出文件的未完成事件
+func (a *multilineAssembler) flush(filePath string) {
+	a.mu.Lock()
+	ev, exists := a.pending[filePath]
+	if exists {
+		ev.timer.Stop()
+		delete(a.pending, filePath)
+	}
+	a.mu.Unlock()
+
+	if exists {
+		a.emit(filePath, ev.event())
+	}
+}
+
+// stop 停止所有定时器并输出未完成事件，避免退出时丢失最后一个事件（如异常堆栈）
+func (a *multilineAssembler) stop() {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[string]*pendingEvent)
+	for _, ev := range pending {
+		ev.timer.Stop()
+	}
+	a.mu.Unlock()
+
+	for filePath, ev := range pending {
+		a.emit(filePath, ev.event())
+	}
+}
+
+// event 拼接事件内容
+func (ev *pendingEvent) event() logLine {
+	text := strings.Join(ev.lines, "\n")
+	if ev.truncated > 0 {
+		text += fmt.Sprintf("\n... 省略 %d 行", ev.truncated)
+	}
+	return logLine{num: ev.firstLine, text: text}
+}
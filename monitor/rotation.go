@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// isReplaced 判断文件自上次读取后是否被截断或替换
+// copytruncate 轮转后文件可能在两次读取之间重新写到超过原位置，仅比较大小无法发现，需要比较头部指纹
+func isReplaced(file *os.File, info os.FileInfo, lastPos int64, prev fileIdentity) bool {
+	if info.Size() < lastPos {
+		return true
+	}
+	if prev.Fingerprint == "" {
+		return false
+	}
+	return !prev.sameFile(file, info)
+}
+
+// isTrackedFile 判断路径上的文件是否已按 inode 跟踪（如轮转时已转移读取位置）
+func (m *LogMonitor) isTrackedFile(filePath string) bool {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+	dev, ino := statDevIno(info)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, exists := m.files[filePath]
+	return exists && state.id.Inode != 0 && state.id.Dev == dev && state.id.Inode == ino
+}
+
+// findRotatedFile 在目录中查找与 id 为同一 inode 的文件，找不到或平台不支持 inode 时返回空
+func (m *LogMonitor) findRotatedFile(dirPath string, id fileIdentity) string {
+	if id.Inode == 0 {
+		return ""
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return ""
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if dev, ino := statDevIno(info); dev == id.Dev && ino == id.Inode {
+			return filepath.Join(dirPath, entry.Name())
+		}
+	}
+
+	return ""
+}
+
+// drainIfReplaced 路径上的文件已被替换为新文件（事件处理滞后于轮转）时，先读完轮转走的旧文件，新文件从头读取
+func (m *LogMonitor) drainIfReplaced(filePath string) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return
+	}
+	dev, ino := statDevIno(info)
+
+	m.mu.Lock()
+	state, tracked := m.files[filePath]
+	if !tracked || state.id.Inode == 0 || (state.id.Dev == dev && state.id.Inode == ino) {
+		m.mu.Unlock()
+		return
+	}
+	m.files[filePath] = fileState{}
+	m.mu.Unlock()
+
+	if rotatedPath := m.findRotatedFile(filepath.Dir(filePath), state.id); rotatedPath != "" {
+		m.drainRotatedFile(filePath, rotatedPath, state)
+		m.flushMultiline(filePath)
+		log.Printf("日志文件已轮转: %s -> %s", filePath, rotatedPath)
+	}
+}
+
+// drainRotatedFile 按原文件的规则读完轮转后文件中尚未处理的内容
+// 轮转后的文件如果仍在监控范围内（如目录监控匹配新扩展名），则保留读取位置继续跟踪
+func (m *LogMonitor) drainRotatedFile(filePath, rotatedPath string, state fileState) {
+	src := m.findSource(filePath)
+	if src == nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.files[rotatedPath] = state
+	m.mu.Unlock()
+
+	m.processNewLines(src, filePath, rotatedPath)
+
+	if m.findSource(rotatedPath) == nil {
+		m.mu.Lock()
+		delete(m.files, rotatedPath)
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIsReplaced(t *testing.T) {
+	const original = "ERROR first line of the old file\n"
+
+	tests := []struct {
+		name    string
+		rewrite func(t *testing.T, path string)
+		want    bool
+	}{
+		{"追加写入", func(t *testing.T, path string) {
+			appendFile(t, path, "ERROR appended\n")
+		}, false},
+		{"截断", func(t *testing.T, path string) {
+			writeFile(t, path, "ERROR\n")
+		}, true},
+		// copytruncate 轮转后在下次读取前已写到超过原位置
+		{"截断后重新写到超过原位置", func(t *testing.T, path string) {
+			writeFile(t, path, "ERROR first line of the new file, already longer than before\n")
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			writeFile(t, path, original)
+			prev := checkpointOf(t, path, 0, 0).fileIdentity
+
+			tt.rewrite(t, path)
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("打开文件失败: %v", err)
+			}
+			defer file.Close()
+			info, err := file.Stat()
+			if err != nil {
+				t.Fatalf("获取文件信息失败: %v", err)
+			}
+
+			if got := isReplaced(file, info, int64(len(original)), prev); got != tt.want {
+				t.Errorf("isReplaced() = %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadNewLinesAfterCopytruncate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	writeFile(t, path, "ERROR old 1\nERROR old 2\n")
+
+	m, _ := newTestMonitor(t, dir)
+	m.files[path] = fileState{}
+	readLines(t, m, path)
+
+	writeFile(t, path, "ERROR new 1\nERROR new 2\nERROR new 3\n")
+	want := []logLine{{1, "ERROR new 1"}, {2, "ERROR new 2"}, {3, "ERROR new 3"}}
+	if got := readLines(t, m, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("copytruncate 后读取到 %v，期望从头读取 %v", got, want)
+	}
+}
+
+func TestHandleFileWriteDrainsRotatedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rotated := filepath.Join(dir, "app.log.1")
+	writeFile(t, path, "ERROR already sent\n")
+
+	m, c := newTestMonitor(t, dir)
+	m.files[path] = fileState{}
+	readLines(t, m, path)
+
+	// 事件处理滞后于轮转：写入、重命名、重新创建后才处理写入事件
+	appendFile(t, path, "ERROR before rotate\n")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("重命名文件失败: %v", err)
+	}
+	writeFile(t, path, "ERROR after rotate\n")
+
+	m.handleFileWrite(path)
+	m.delivering.Wait()
+
+	got := c.received()
+	sort.Strings(got)
+	if want := []string{"ERROR after rotate", "ERROR before rotate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("发送了 %v，期望 %v", got, want)
+	}
+	if _, tracked := m.files[rotated]; tracked {
+		t.Errorf("不在监控范围内的轮转文件读完后不应继续跟踪")
+	}
+}
+
+func TestHandleFileCreateSkipsTrackedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	renamed := filepath.Join(dir, "app-1.log")
+	writeFile(t, path, "ERROR already sent\n")
+
+	m, c := newTestMonitor(t, dir)
+	m.files[path] = fileState{}
+	readLines(t, m, path)
+
+	// 轮转到仍在监控范围内的新名称，读取位置已随 inode 转移
+	if err := os.Rename(path, renamed); err != nil {
+		t.Fatalf("重命名文件失败: %v", err)
+	}
+	m.files[renamed] = m.files[path]
+	delete(m.files, path)
+
+	m.handleFileCreate(renamed)
+	m.delivering.Wait()
+	if got := c.received(); len(got) != 0 {
+		t.Errorf("已跟踪的文件不应从头读取，发送了 %v", got)
+	}
+
+	appendFile(t, renamed, "ERROR appended\n")
+	want := []logLine{{2, "ERROR appended"}}
+	if got := readLines(t, m, renamed); !reflect.DeepEqual(got, want) {
+		t.Errorf("读取到 %v，期望沿用原读取位置 %v", got, want)
+	}
+}
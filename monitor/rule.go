@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+// maxThresholdSamples 阈值告警中附带的样例行数
+const maxThresholdSamples = 5
+
+// rule 编译后的告警规则
+type rule struct {
+	name      string            // 规则名称，文件级 keywords/patterns 组成的默认规则为空
+	matcher   *matcher          // 匹配规则
+	threshold *thresholdCounter // 阈值计数器，未配置时为nil
+	mention   *alert.Mention    // 规则配置的 @提醒，未配置时为nil
+	notifiers []string          // 告警路由的通知器名称，未配置时使用日志文件/目录的路由
+	severity  string            // 告警级别，未配置时使用日志文件/目录的级别
+}
+
+// newRule 编译命名告警规则
+func newRule(cfg *config.Rule) (*rule, error) {
+	mt, err := newSeverityMatcher(cfg.Keywords, cfg.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rule{name: cfg.Name, matcher: mt, mention: cfg.Mention.AlertMention(), notifiers: cfg.Notifiers, severity: cfg.Severity}
+	if cfg.Threshold != nil {
+		r.threshold = newThresholdCounter(cfg.Threshold.Count, cfg.Threshold.Window)
+	}
+	return r, nil
+}
+
+// thresholdCounter 按文件统计的滑动窗口计数器
+type thresholdCounter struct {
+	count  int
+	window time.Duration
+
+	mu   sync.Mutex
+	hits map[string][]thresholdHit // 按文件路径索引的窗口内命中记录
+}
+
+// thresholdHit 单次命中记录
+type thresholdHit struct {
+	at   time.Time
+	line string
+}
+
+// newThresholdCounter 创建滑动窗口计数器
+func newThresholdCounter(count int, window time.Duration) *thresholdCounter {
+	return &thresholdCounter{
+		count:  count,
+		window: window,
+		hits:   make(map[string][]thresholdHit),
+	}
+}
+
+// add 记录一次命中，窗口内命中次数达到阈值时返回 true 以及命中次数和最近的样例行，并重新开始计数
+func (c *thresholdCounter) add(filePath, line string, now time.Time) (fired bool, count int, samples []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 丢弃窗口外的命中记录
+	hits := c.hits[filePath]
+	cutoff := now.Add(-c.window)
+	start := 0
+	for start < len(hits) && !hits[start].at.After(cutoff) {
+		start++
+	}
+	hits = append(hits[start:], thresholdHit{at: now, line: line})
+
+	if len(hits) < c.count {
+		c.hits[filePath] = hits
+		return false, 0, nil
+	}
+
+	delete(c.hits, filePath)
+	first := len(hits) - maxThresholdSamples
+	if first < 0 {
+		first = 0
+	}
+	for _, hit := range hits[first:] {
+		samples = append(samples, hit.line)
+	}
+	return true, len(hits), samples
+}
+
+// prune 清理窗口已过期的文件记录
+func (c *thresholdCounter) prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := now.Add(-c.window)
+	for filePath, hits := range c.hits {
+		if len(hits) == 0 || !hits[len(hits)-1].at.After(cutoff) {
+			delete(c.hits, filePath)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package monitor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+func TestThresholdCounterAdd(t *testing.T) {
+	base := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+
+	type hit struct {
+		file   string
+		offset time.Duration // 相对 base 的命中时间
+	}
+	tests := []struct {
+		name        string
+		count       int
+		window      time.Duration
+		hits        []hit
+		wantFired   bool
+		wantCount   int
+		wantSamples int
+	}{
+		{
+			name:   "未达到阈值",
+			count:  3,
+			window: time.Minute,
+			hits:   []hit{{"a.log", 0}, {"a.log", time.Second}},
+		},
+		{
+			name:        "窗口内达到阈值",
+			count:       3,
+			window:      time.Minute,
+			hits:        []hit{{"a.log", 0}, {"a.log", time.Second}, {"a.log", 2 * time.Second}},
+			wantFired:   true,
+			wantCount:   3,
+			wantSamples: 3,
+		},
+		{
+			name:   "窗口外的命中不计数",
+			count:  3,
+			window: time.Minute,
+			hits:   []hit{{"a.log", 0}, {"a.log", time.Second}, {"a.log", 61 * time.Second}},
+		},
+		{
+			name:   "恰好在窗口边界的命中已过期",
+			count:  2,
+			window: time.Minute,
+			hits:   []hit{{"a.log", 0}, {"a.log", time.Minute}},
+		},
+		{
+			name:   "按文件分别计数",
+			count:  2,
+			window: time.Minute,
+			hits:   []hit{{"a.log", 0}, {"b.log", time.Second}},
+		},
+		{
+			name:        "阈值为1时每次命中都触发",
+			count:       1,
+			window:      time.Minute,
+			hits:        []hit{{"a.log", 0}},
+			wantFired:   true,
+			wantCount:   1,
+			wantSamples: 1,
+		},
+		{
+			name:   "触发后重新计数",
+			count:  2,
+			window: time.Minute,
+			hits:   []hit{{"a.log", 0}, {"a.log", time.Second}, {"a.log", 2 * time.Second}},
+		},
+		{
+			name:        "样例最多保留最近几行",
+			count:       maxThresholdSamples + 2,
+			window:      time.Minute,
+			hits:        []hit{{"a.log", 0}, {"a.log", 1}, {"a.log", 2}, {"a.log", 3}, {"a.log", 4}, {"a.log", 5}, {"a.log", 6}},
+			wantFired:   true,
+			wantCount:   maxThresholdSamples + 2,
+			wantSamples: maxThresholdSamples,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newThresholdCounter(tt.count, tt.window)
+			var fired bool
+			var count int
+			var samples []string
+			for i, h := range tt.hits {
+				fired, count, samples = c.add(h.file, fmt.Sprintf("line %d", i), base.Add(h.offset))
+			}
+
+			if fired != tt.wantFired || count != tt.wantCount || len(samples) != tt.wantSamples {
+				t.Fatalf("最后一次 add = (%v, %d, %d 条样例)，期望 (%v, %d, %d 条样例)",
+					fired, count, len(samples), tt.wantFired, tt.wantCount, tt.wantSamples)
+			}
+			if fired {
+				// 样例为最近的命中行，按命中顺序排列
+				var want []string
+				for i := len(tt.hits) - tt.wantSamples; i < len(tt.hits); i++ {
+					want = append(want, fmt.Sprintf("line %d", i))
+				}
+				if !reflect.DeepEqual(samples, want) {
+					t.Errorf("样例 = %v，期望 %v", samples, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceMatchSeverity(t *testing.T) {
+	src, err := newSource(sourceConfig{
+		path: "/var/log/app.log",
+		keywords: []config.Match{
+			{Value: "FATAL", Severity: alert.SeverityCritical},
+			{Value: "WARN", Severity: alert.SeverityInfo},
+			{Value: "ERROR"},
+		},
+		patterns: []config.Match{{Value: `status=5\d\d`, Severity: alert.SeverityCritical}},
+		rules: []config.Rule{{
+			Name:     "db",
+			Keywords: []config.Match{{Value: "deadlock", Severity: alert.SeverityCritical}, {Value: "slow query"}},
+			Severity: alert.SeverityInfo,
+		}},
+		severity: alert.SeverityWarning,
+	})
+	if err != nil {
+		t.Fatalf("创建监控源失败: %v", err)
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"FATAL out of memory", alert.SeverityCritical},
+		{"WARN disk usage 80%", alert.SeverityInfo},
+		{"ERROR connection refused", alert.SeverityWarning},
+		{"GET /api status=502", alert.SeverityCritical},
+		{"deadlock detected", alert.SeverityCritical},
+		{"slow query 3.2s", alert.SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		result, _ := src.match(tt.line)
+		if result == nil {
+			t.Errorf("%q 未命中", tt.line)
+			continue
+		}
+		if got := result.alertSeverity(); got != tt.want {
+			t.Errorf("%q 的告警级别 = %s，期望 %s", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+	"log-monitor/notifier"
+)
+
+const (
+	silenceCheckInterval = 10 * time.Second // 检查屏蔽窗口结束、重新加载运行时屏蔽文件的间隔
+	maxSilenceSamples    = 5                // 屏蔽汇总中附带的样例行数
+)
+
+// silence 编译后的告警屏蔽
+type silence struct {
+	cfg      config.Silence
+	location *time.Location
+	weekdays map[time.Weekday]bool // 生效的星期，为nil时每天生效
+	from, to int                   // 每天的开始、结束时间（距零点的分钟数）
+
+	// 窗口状态，由 silencer 的锁保护
+	active   bool
+	since    time.Time
+	count    int
+	severity string          // 被屏蔽告警的最高级别
+	files    map[string]bool // 被屏蔽告警的文件
+	missed   map[string]bool // 错过告警的通知器名称
+	samples  []string
+}
+
+// silenceSummary 屏蔽窗口结束时的汇总
+type silenceSummary struct {
+	name      string
+	since     time.Time
+	until     time.Time
+	count     int
+	severity  string
+	files     []string
+	notifiers []string
+	samples   []string
+}
+
+// newSilence 编译告警屏蔽配置
+func newSilence(cfg config.Silence) (*silence, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &silence{cfg: cfg, location: time.Local}
+	if !cfg.Recurring() {
+		return s, nil
+	}
+
+	s.from, _ = config.ParseClock(cfg.StartTime)
+	s.to, _ = config.ParseClock(cfg.EndTime)
+	if cfg.Timezone != "" {
+		s.location, _ = time.LoadLocation(cfg.Timezone)
+	}
+	if len(cfg.Weekdays) > 0 {
+		s.weekdays = make(map[time.Weekday]bool)
+		for _, day := range cfg.Weekdays {
+			weekday, _ := config.ParseWeekday(day)
+			s.weekdays[weekday] = true
+		}
+	}
+	return s, nil
+}
+
+// inWindow 当前时间是否在屏蔽窗口内
+func (s *silence) inWindow(now time.Time) bool {
+	if !s.cfg.Recurring() {
+		return !now.Before(s.cfg.StartsAt) && now.Before(s.cfg.EndsAt)
+	}
+
+	t := now.In(s.location)
+	minute := t.Hour()*60 + t.Minute()
+	if s.from < s.to {
+		return s.onDay(t) && minute >= s.from && minute < s.to
+	}
+	// 跨天窗口：开始当天的 from 之后，或者次日的 to 之前
+	if minute >= s.from {
+		return s.onDay(t)
+	}
+	return minute < s.to && s.onDay(t.AddDate(0, 0, -1))
+}
+
+// onDay 屏蔽是否在指定日期生效
+func (s *silence) onDay(t time.Time) bool {
+	return s.weekdays == nil || s.weekdays[t.Weekday()]
+}
+
+// matches 告警是否在屏蔽的文件和规则范围内
+func (s *silence) matches(a *alert.Alert) bool {
+	if len(s.cfg.Files) > 0 {
+		matched := false
+		for _, pattern := range s.cfg.Files {
+			if ok, _ := filepath.Match(pattern, a.File); ok || pattern == a.File {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(s.cfg.Rules) > 0 {
+		return containsName(s.cfg.Rules, a.Rule)
+	}
+	return true
+}
+
+// covers 屏蔽是否作用于指定通知器
+func (s *silence) covers(name string) bool {
+	return len(s.cfg.Notifiers) == 0 || containsName(s.cfg.Notifiers, name)
+}
+
+// start 开始屏蔽窗口
+func (s *silence) start(now time.Time) {
+	s.active = true
+	s.since = now
+	log.Printf("告警屏蔽 %s 已生效", s.cfg.Name)
+}
+
+// record 记录一条被屏蔽的告警
+func (s *silence) record(a *alert.Alert, missed []string, now time.Time) {
+	if !s.active {
+		s.start(now)
+	}
+	s.count++
+	if alert.SeverityRank(a.Severity) > alert.SeverityRank(s.severity) {
+		s.severity = a.Severity
+	}
+	if s.files == nil {
+		s.files = make(map[string]bool)
+		s.missed = make(map[string]bool)
+	}
+	s.files[a.File] = true
+	for _, name := range missed {
+		s.missed[name] = true
+	}
+	if len(s.samples) < maxSilenceSamples {
+		s.samples = append(s.samples, a.Line)
+	}
+}
+
+// end 结束屏蔽窗口，返回需要发送的汇总，没有需要汇总的告警时返回nil
+func (s *silence) end(now time.Time) *silenceSummary {
+	log.Printf("告警屏蔽 %s 已结束，共屏蔽 %d 条告警", s.cfg.Name, s.count)
+
+	var summary *silenceSummary
+	if s.cfg.Summary && s.count > 0 {
+		summary = &silenceSummary{
+			name:      s.cfg.Name,
+			since:     s.since,
+			until:     now,
+			count:     s.count,
+			severity:  s.severity,
+			files:     sortedKeys(s.files),
+			notifiers: sortedKeys(s.missed),
+			samples:   s.samples,
+		}
+	}
+
+	s.active = false
+	s.since = time.Time{}
+	s.count = 0
+	s.severity = ""
+	s.files = nil
+	s.missed = nil
+	s.samples = nil
+	return summary
+}
+
+// sortedKeys 排序后的集合元素
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// silencer 告警屏蔽器，配置文件中的屏蔽和运行时屏蔽文件中的屏蔽同时生效
+type silencer struct {
+	runtimePath string                        // 运行时屏蔽文件，未配置时为空
+	summarize   func(summary *silenceSummary) // 屏蔽汇总回调
+
+	mu         sync.Mutex
+	static     []*silence
+	runtime    []*silence
+	runtimeMod time.Time // 运行时屏蔽文件的修改时间
+}
+
+// newSilencer 创建告警屏蔽器并加载运行时屏蔽文件
+func newSilencer(silences []config.Silence, runtimePath string) (*silencer, error) {
+	s := &silencer{runtimePath: runtimePath}
+	for _, cfg := range silences {
+		sl, err := newSilence(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("告警屏蔽%v", err)
+		}
+		s.static = append(s.static, sl)
+	}
+	s.reload()
+	return s, nil
+}
+
+// filter 过滤掉告警被屏蔽的通知器，返回保留的通知器和被屏蔽的通知器名称
+// 记录类通知器（告警记录文件）不受屏蔽影响，被屏蔽的告警也会完整记录
+func (s *silencer) filter(a *alert.Alert, notifiers []notifier.Notifier, now time.Time) (kept []notifier.Notifier, silenced []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept = notifiers
+	for _, sl := range s.all() {
+		if !sl.matches(a) || !sl.inWindow(now) {
+			continue
+		}
+
+		var next []notifier.Notifier
+		var missed []string
+		for _, n := range kept {
+			if _, ok := n.(notifier.Recorder); ok {
+				next = append(next, n)
+			} else if name := notifier.NameOf(n); sl.covers(name) {
+				missed = append(missed, name)
+			} else {
+				next = append(next, n)
+			}
+		}
+		if len(missed) > 0 {
+			sl.record(a, missed, now)
+			silenced = append(silenced, missed...)
+		}
+		kept = next
+	}
+	return kept, silenced
+}
+
+// check 更新屏蔽窗口状态，重新加载运行时屏蔽文件，并为已结束的窗口发送汇总
+func (s *silencer) check(now time.Time) {
+	var summaries []*silenceSummary
+
+	s.mu.Lock()
+	summaries = append(summaries, s.reload()...)
+	for _, sl := range s.all() {
+		inWindow := sl.inWindow(now)
+		switch {
+		case inWindow && !sl.active:
+			sl.start(now)
+		case !inWindow && sl.active:
+			if summary := sl.end(now); summary != nil {
+				summaries = append(summaries, summary)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	for _, summary := range summaries {
+		s.summarize(summary)
+	}
+}
+
+// all 所有告警屏蔽，调用方需持有锁
+func (s *silencer) all() []*silence {
+	return append(s.static[:len(s.static):len(s.static)], s.runtime...)
+}
+
+// reload 运行时屏蔽文件变化时重新加载，同名屏蔽保留窗口状态，被删除的屏蔽视为结束，调用方需持有锁
+func (s *silencer) reload() []*silenceSummary {
+	if s.runtimePath == "" {
+		return nil
+	}
+
+	var modTime time.Time
+	if stat, err := os.Stat(s.runtimePath); err == nil {
+		modTime = stat.ModTime()
+	} else if !os.IsNotExist(err) {
+		log.Printf("读取告警屏蔽文件失败: %v", err)
+		return nil
+	}
+	if modTime.Equal(s.runtimeMod) {
+		return nil
+	}
+
+	silences, err := config.LoadSilences(s.runtimePath)
+	if err != nil {
+		log.Printf("%v", err)
+		return nil
+	}
+	s.runtimeMod = modTime
+
+	previous := make(map[string]*silence)
+	for _, sl := range s.runtime {
+		previous[sl.cfg.Name] = sl
+	}
+	names := make(map[string]bool)
+	for _, sl := range s.static {
+		names[sl.cfg.Name] = true
+	}
+
+	var runtime []*silence
+	for _, cfg := range silences {
+		if names[cfg.Name] {
+			log.Printf("告警屏蔽文件中的屏蔽 %s 名称重复，已忽略", cfg.Name)
+			continue
+		}
+		sl, err := newSilence(cfg)
+		if err != nil {
+			log.Printf("告警屏蔽文件中的屏蔽无效，已忽略: %v", err)
+			continue
+		}
+		names[cfg.Name] = true
+		if old, ok := previous[cfg.Name]; ok {
+			sl.active, sl.since, sl.count, sl.severity = old.active, old.since, old.count, old.severity
+			sl.files, sl.missed, sl.samples = old.files, old.missed, old.samples
+			delete(previous, cfg.Name)
+		}
+		runtime = append(runtime, sl)
+	}
+
+	var summaries []*silenceSummary
+	for _, old := range previous {
+		if old.active {
+			if summary := old.end(time.Now()); summary != nil {
+				summaries = append(summaries, summary)
+			}
+		}
+	}
+
+	s.runtime = runtime
+	log.Printf("已加载告警屏蔽文件 %s，共 %d 条屏蔽", s.runtimePath, len(runtime))
+	return summaries
+}
+
+// silenceLoop 定期检查告警屏蔽窗口
+func (m *LogMonitor) silenceLoop() {
+	ticker := time.NewTicker(silenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.silencer.check(time.Now())
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// sendSilenceSummary 发送屏蔽汇总，只发送到屏蔽期间错过告警的通知器
+func (m *LogMonitor) sendSilenceSummary(summary *silenceSummary) {
+	m.dispatch(&alert.Alert{
+		Kind:      alert.KindSilenced,
+		Title:     "🔇 告警屏蔽汇总",
+		File:      joinFiles(summary.files),
+		Hostname:  m.hostname,
+		Severity:  summary.severity,
+		Line:      fmt.Sprintf("告警屏蔽 %s 已结束（%s ~ %s）", summary.name, summary.since.Format("01-02 15:04"), summary.until.Format("01-02 15:04")),
+		Time:      summary.until,
+		Summary:   fmt.Sprintf("屏蔽期间共 %d 条告警", summary.count),
+		Samples:   summary.samples,
+		Notifiers: summary.notifiers,
+	})
+}
+
+// joinFiles 汇总中的文件列表
+func joinFiles(files []string) string {
+	if len(files) <= 3 {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s 等 %d 个文件", files[0], len(files))
+}
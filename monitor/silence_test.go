@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"log-monitor/config"
+)
+
+func TestSilenceInWindow(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("加载时区失败: %v", err)
+	}
+	// 2024-01-05 为星期五
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.Silence
+		now  time.Time
+		want bool
+	}{
+		{"白天窗口内", config.Silence{StartTime: "09:00", EndTime: "18:00"}, at(5, 12, 0), true},
+		{"白天窗口开始时刻", config.Silence{StartTime: "09:00", EndTime: "18:00"}, at(5, 9, 0), true},
+		{"白天窗口结束时刻", config.Silence{StartTime: "09:00", EndTime: "18:00"}, at(5, 18, 0), false},
+		{"白天窗口之前", config.Silence{StartTime: "09:00", EndTime: "18:00"}, at(5, 8, 59), false},
+		{"跨天窗口当晚", config.Silence{StartTime: "22:00", EndTime: "06:00"}, at(5, 23, 30), true},
+		{"跨天窗口次日凌晨", config.Silence{StartTime: "22:00", EndTime: "06:00"}, at(6, 5, 59), true},
+		{"跨天窗口次日结束后", config.Silence{StartTime: "22:00", EndTime: "06:00"}, at(6, 6, 0), false},
+		{"跨天窗口开始前", config.Silence{StartTime: "22:00", EndTime: "06:00"}, at(5, 21, 59), false},
+		{"星期限定当天", config.Silence{StartTime: "09:00", EndTime: "18:00", Weekdays: []string{"fri"}}, at(5, 10, 0), true},
+		{"星期限定其他日期", config.Silence{StartTime: "09:00", EndTime: "18:00", Weekdays: []string{"fri"}}, at(6, 10, 0), false},
+		{"跨天窗口按开始日期计算星期", config.Silence{StartTime: "22:00", EndTime: "06:00", Weekdays: []string{"fri"}}, at(6, 3, 0), true},
+		{"跨天窗口前一天不生效", config.Silence{StartTime: "22:00", EndTime: "06:00", Weekdays: []string{"fri"}}, at(5, 3, 0), false},
+		{"跨天窗口当晚不生效", config.Silence{StartTime: "22:00", EndTime: "06:00", Weekdays: []string{"fri"}}, at(6, 23, 0), false},
+		// 上海时间 2024-01-06 02:00（星期六）
+		{"按配置时区计算", config.Silence{StartTime: "01:00", EndTime: "03:00", Timezone: "Asia/Shanghai", Weekdays: []string{"sat"}}, time.Date(2024, 1, 6, 2, 0, 0, 0, shanghai).UTC(), true},
+		{"一次性窗口内", config.Silence{StartsAt: at(5, 10, 0), EndsAt: at(5, 11, 0)}, at(5, 10, 30), true},
+		{"一次性窗口结束时刻", config.Silence{StartsAt: at(5, 10, 0), EndsAt: at(5, 11, 0)}, at(5, 11, 0), false},
+		{"一次性窗口之前", config.Silence{StartsAt: at(5, 10, 0), EndsAt: at(5, 11, 0)}, at(5, 9, 59), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Name = "test"
+			if tt.cfg.Recurring() && tt.cfg.Timezone == "" {
+				tt.cfg.Timezone = "UTC"
+			}
+			s, err := newSilence(tt.cfg)
+			if err != nil {
+				t.Fatalf("创建告警屏蔽失败: %v", err)
+			}
+			if got := s.inWindow(tt.now); got != tt.want {
+				t.Errorf("inWindow(%s) = %v，期望 %v", tt.now.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
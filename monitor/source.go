@@ -0,0 +1,133 @@
+package monitor
+
+import (
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+// source 监控源（日志文件或日志目录）编译后的运行时规则
+type source struct {
+	path      string              // 配置中的路径
+	rules     []*rule             // 告警规则，按顺序匹配，第一条命中的规则生效
+	exclude   *matcher            // 排除规则，命中则丢弃
+	multiline *multilineAssembler // 多行事件合并器，未配置时为nil
+	heartbeat *heartbeat          // 静默检测，未配置时为nil
+	notifiers []string            // 告警路由的通知器名称，未配置时使用默认路由
+	severity  string              // 默认规则和静默检测的告警级别
+}
+
+// sourceConfig 日志文件和日志目录共有的规则配置
+type sourceConfig struct {
+	path            string
+	keywords        []config.Match
+	patterns        []config.Match
+	rules           []config.Rule
+	excludeKeywords []string
+	excludePatterns []string
+	multiline       *config.Multiline
+	expectWithin    time.Duration
+	expectPattern   string
+	notifiers       []string
+	severity        string
+}
+
+// newFileSource 根据日志文件配置创建监控源
+func newFileSource(logFile *config.LogFile) (*source, error) {
+	return newSource(sourceConfig{
+		path:            logFile.Path,
+		keywords:        logFile.Keywords,
+		patterns:        logFile.Patterns,
+		rules:           logFile.Rules,
+		excludeKeywords: logFile.ExcludeKeywords,
+		excludePatterns: logFile.ExcludePatterns,
+		multiline:       logFile.Multiline,
+		expectWithin:    logFile.ExpectActivityWithin,
+		expectPattern:   logFile.ExpectPattern,
+		notifiers:       logFile.Notifiers,
+		severity:        logFile.Severity,
+	})
+}
+
+// newDirSource 根据日志目录配置创建监控源
+func newDirSource(logDir *config.LogDirectory) (*source, error) {
+	return newSource(sourceConfig{
+		path:            logDir.Path,
+		keywords:        logDir.Keywords,
+		patterns:        logDir.Patterns,
+		rules:           logDir.Rules,
+		excludeKeywords: logDir.ExcludeKeywords,
+		excludePatterns: logDir.ExcludePatterns,
+		multiline:       logDir.Multiline,
+		expectWithin:    logDir.ExpectActivityWithin,
+		expectPattern:   logDir.ExpectPattern,
+		notifiers:       logDir.Notifiers,
+		severity:        logDir.Severity,
+	})
+}
+
+// newSource 编译监控源的告警规则和排除规则
+func newSource(cfg sourceConfig) (*source, error) {
+	src := &source{path: cfg.path, notifiers: cfg.notifiers, severity: cfg.severity}
+	if src.severity == "" {
+		src.severity = alert.SeverityWarning
+	}
+
+	// 命名规则优先匹配，文件级 keywords/patterns 作为默认规则放在最后
+	for i := range cfg.rules {
+		r, err := newRule(&cfg.rules[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(r.notifiers) == 0 {
+			r.notifiers = cfg.notifiers
+		}
+		if r.severity == "" {
+			r.severity = src.severity
+		}
+		src.rules = append(src.rules, r)
+	}
+	if len(cfg.keywords) > 0 || len(cfg.patterns) > 0 {
+		mt, err := newSeverityMatcher(cfg.keywords, cfg.patterns)
+		if err != nil {
+			return nil, err
+		}
+		src.rules = append(src.rules, &rule{matcher: mt, notifiers: cfg.notifiers, severity: src.severity})
+	}
+
+	exclude, err := newMatcher(cfg.excludeKeywords, cfg.excludePatterns)
+	if err != nil {
+		return nil, err
+	}
+	src.exclude = exclude
+
+	if cfg.multiline != nil {
+		if src.multiline, err = newMultilineAssembler(cfg.multiline); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.expectWithin > 0 {
+		if src.heartbeat, err = newHeartbeat(cfg.expectWithin, cfg.expectPattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return src, nil
+}
+
+// match 检查行是否需要告警，excluded 表示命中告警规则但被排除规则过滤
+func (s *source) match(line string) (result *matchResult, excluded bool) {
+	for _, r := range s.rules {
+		if result, ok := r.matcher.match(line); ok {
+			if _, hit := s.exclude.match(line); hit {
+				return nil, true
+			}
+			result.rule = r
+			return result, false
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"sync/atomic"
+)
+
+// Stats 监控统计信息
+type Stats struct {
+	LinesRead  int64 // 读取的日志行数
+	Matched    int64 // 命中告警规则的行数
+	Excluded   int64 // 命中告警规则但被排除规则过滤的行数
+	Suppressed int64 // 去重窗口内被抑制的重复告警数
+	Silenced   int64 // 被告警屏蔽的告警数（含只对部分通知器屏蔽的告警）
+	AlertsSent int64 // 发出的告警数（含重复汇总）
+}
+
+// monitorStats 并发安全的统计计数器
+type monitorStats struct {
+	linesRead  atomic.Int64
+	matched    atomic.Int64
+	excluded   atomic.Int64
+	suppressed atomic.Int64
+	silenced   atomic.Int64
+	alertsSent atomic.Int64
+}
+
+// snapshot 获取统计快照
+func (s *monitorStats) snapshot() Stats {
+	return Stats{
+		LinesRead:  s.linesRead.Load(),
+		Matched:    s.matched.Load(),
+		Excluded:   s.excluded.Load(),
+		Suppressed: s.suppressed.Load(),
+		Silenced:   s.silenced.Load(),
+		AlertsSent: s.alertsSent.Load(),
+	}
+}
+
+// Stats 返回当前统计信息
+func (m *LogMonitor) Stats() Stats {
+	return m.stats.snapshot()
+}
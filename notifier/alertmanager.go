@@ -0,0 +1,238 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const alertmanagerPath = "/api/v2/alerts"
+
+// amAlert Alertmanager v2 API 告警
+type amAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// amActive 仍在持续的告警
+type amActive struct {
+	alert    amAlert
+	lastSeen time.Time
+	// 静默告警在收到恢复通知前一直持续，其他告警在 resolve_timeout 内没有再次出现时视为已恢复
+	untilRecovery bool
+}
+
+// AlertmanagerNotifier 将告警推送到 Prometheus Alertmanager，由 Alertmanager 负责分组、抑制和静默
+type AlertmanagerNotifier struct {
+	url            string
+	headers        map[string]string
+	bearerToken    string
+	resolveTimeout time.Duration
+	labels         map[string]string
+	generatorURL   string
+
+	mu     sync.Mutex
+	active map[string]*amActive // 按标签区分的持续中的告警
+}
+
+// NewAlertmanagerNotifier 创建 Alertmanager 通知器，并启动定期重发任务
+func NewAlertmanagerNotifier(cfg config.Notifier) *AlertmanagerNotifier {
+	resolveTimeout, resendInterval := cfg.Alertmanager.Intervals()
+	am := &AlertmanagerNotifier{
+		url:            strings.TrimRight(cfg.Webhook, "/"),
+		headers:        cfg.Headers,
+		bearerToken:    cfg.BearerToken,
+		resolveTimeout: resolveTimeout,
+		active:         make(map[string]*amActive),
+	}
+	if !strings.HasSuffix(am.url, alertmanagerPath) {
+		am.url += alertmanagerPath
+	}
+	if c := cfg.Alertmanager; c != nil {
+		am.labels = c.Labels
+		am.generatorURL = c.GeneratorURL
+	}
+
+	go am.resendLoop(resendInterval)
+	return am
+}
+
+// Send 推送告警，恢复通知会结束对应的静默告警
+func (am *AlertmanagerNotifier) Send(a *alert.Alert) error {
+	labels := am.alertLabels(a)
+	key := labelKey(labels)
+
+	am.mu.Lock()
+	entry, ok := am.active[key]
+	if !ok {
+		entry = &amActive{
+			alert: amAlert{
+				Labels:       labels,
+				StartsAt:     a.Time,
+				GeneratorURL: am.generatorURL,
+			},
+			untilRecovery: a.Kind == alert.KindSilence,
+		}
+	}
+	if a.Time.After(entry.lastSeen) {
+		// 死信队列重放的旧告警不会缩短告警的持续时间
+		entry.lastSeen = a.Time
+	}
+	entry.alert.Annotations = alertAnnotations(a, entry.alert.Annotations)
+	if a.Kind == alert.KindRecovery {
+		entry.alert.EndsAt = a.Time
+		delete(am.active, key)
+	} else {
+		entry.alert.EndsAt = am.endsAt(entry)
+		am.active[key] = entry
+	}
+	payload := entry.alert
+	am.mu.Unlock()
+
+	return am.post([]amAlert{payload})
+}
+
+// resendLoop 定期重发持续中的告警，使 Alertmanager 在告警持续期间不会自动恢复
+func (am *AlertmanagerNotifier) resendLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var alerts []amAlert
+		now := time.Now()
+
+		am.mu.Lock()
+		for key, entry := range am.active {
+			if !entry.untilRecovery && now.Sub(entry.lastSeen) >= am.resolveTimeout {
+				// 超过恢复时间没有再次出现，Alertmanager 已按 endsAt 自动恢复
+				delete(am.active, key)
+				continue
+			}
+			entry.alert.EndsAt = am.endsAt(entry)
+			alerts = append(alerts, entry.alert)
+		}
+		am.mu.Unlock()
+
+		if len(alerts) == 0 {
+			continue
+		}
+		if err := am.post(alerts); err != nil {
+			log.Printf("alertmanager 重发 %d 条持续中的告警失败: %v", len(alerts), err)
+		}
+	}
+}
+
+// endsAt 告警的结束时间，静默告警从当前时间起算，其他告警从最后一次出现起算
+func (am *AlertmanagerNotifier) endsAt(entry *amActive) time.Time {
+	if entry.untilRecovery {
+		return time.Now().Add(am.resolveTimeout)
+	}
+	return entry.lastSeen.Add(am.resolveTimeout)
+}
+
+// alertLabels 告警标签，相同标签的告警在 Alertmanager 中是同一个告警
+func (am *AlertmanagerNotifier) alertLabels(a *alert.Alert) map[string]string {
+	labels := make(map[string]string, len(am.labels)+5)
+	for name, value := range am.labels {
+		labels[name] = value
+	}
+
+	switch a.Kind {
+	case alert.KindSilence, alert.KindRecovery:
+		labels["alertname"] = "LogSilence"
+	case alert.KindSilenced:
+		labels["alertname"] = "LogSilencedSummary"
+	default:
+		labels["alertname"] = "LogMatch"
+	}
+	labels["file"] = a.File
+	labels["host"] = a.Hostname
+	labels["severity"] = a.Severity
+	if a.Severity == "" {
+		// 升级前写入死信队列的告警没有级别
+		labels["severity"] = alert.SeverityWarning
+	}
+	if a.Rule != "" {
+		labels["rule"] = a.Rule
+	}
+	return labels
+}
+
+// alertAnnotations 告警注释，重复汇总只更新次数，保留最近一次告警的日志内容
+func alertAnnotations(a *alert.Alert, prev map[string]string) map[string]string {
+	annotations := map[string]string{
+		"summary": a.Title,
+		"line":    a.Line,
+	}
+	if a.Kind == alert.KindRepeat && prev != nil {
+		annotations["summary"] = prev["summary"]
+	}
+	if a.Keyword != "" {
+		annotations["keyword"] = a.Keyword
+	}
+	if a.LineNumber > 0 {
+		annotations["line_number"] = strconv.FormatInt(a.LineNumber, 10)
+	}
+	if a.Summary != "" {
+		annotations["count"] = a.Summary
+	}
+	if len(a.Fields) > 0 {
+		annotations["fields"] = alert.FormatFields(a.Fields)
+	}
+	return annotations
+}
+
+// labelKey 将标签转换为用于区分告警的字符串
+func labelKey(labels map[string]string) string {
+	data, _ := json.Marshal(labels) // map 按键排序序列化
+	return string(data)
+}
+
+// post 发送告警到 Alertmanager
+func (am *AlertmanagerNotifier) post(alerts []amAlert) error {
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, am.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建HTTP请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if am.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+am.bearerToken)
+	}
+	for name, value := range am.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if resp.StatusCode == http.StatusBadRequest {
+			// 标签或时间格式不合法
+			return &SendError{Kind: ErrContentRejected, Code: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+		}
+		return statusError(resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+	"log-monitor/internal/fileutil"
+)
+
+const defaultQueueMaxSize = 1000 // 默认每个通知器最多保留的告警数
+
+// deadLetterQueue 最终发送失败的告警队列，每行一条 JSON 格式的告警，重启后继续重放
+type deadLetterQueue struct {
+	path    string
+	maxSize int
+
+	mu      sync.Mutex
+	entries []*alert.Alert
+}
+
+// queueName 根据通知器类型和投递目标生成队列文件名，配置顺序变化时仍能对应到同一个通知器
+func queueName(cfg config.Notifier) string {
+	target := []string{cfg.Type, cfg.Webhook}
+	if cfg.SMTP != nil {
+		target = append(target, cfg.SMTP.Host, cfg.SMTP.From, strings.Join(cfg.SMTP.To, ","))
+	}
+	if cfg.Telegram != nil {
+		target = append(target, cfg.Telegram.BotToken, cfg.Telegram.ChatID)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(target, "\n")))
+	return cfg.Type + "-" + hex.EncodeToString(sum[:6]) + ".jsonl"
+}
+
+// openDeadLetterQueue 打开死信队列并加载上次退出时未重放的告警
+func openDeadLetterQueue(dir, name string, maxSize int) (*deadLetterQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建死信队列目录失败: %v", err)
+	}
+
+	q := &deadLetterQueue{
+		path:    filepath.Join(dir, name),
+		maxSize: maxSize,
+	}
+	if q.maxSize <= 0 {
+		q.maxSize = defaultQueueMaxSize
+	}
+
+	file, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开死信队列失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var a alert.Alert
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			// 写入过程中进程退出可能留下不完整的行
+			log.Printf("跳过无法解析的死信记录 %s: %v", q.path, err)
+			continue
+		}
+		q.entries = append(q.entries, &a)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取死信队列失败: %v", err)
+	}
+
+	if len(q.entries) > q.maxSize {
+		q.entries = q.entries[len(q.entries)-q.maxSize:]
+		if err := q.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// push 追加告警，超出容量时丢弃最早的告警
+func (q *deadLetterQueue) push(a *alert.Alert) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("序列化告警失败: %v", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = append(q.entries, a)
+	if len(q.entries) > q.maxSize {
+		dropped := len(q.entries) - q.maxSize
+		q.entries = q.entries[dropped:]
+		log.Printf("死信队列已满，丢弃最早的 %d 条告警: %s", dropped, q.path)
+		return q.rewrite()
+	}
+
+	file, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开死信队列失败: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入死信队列失败: %v", err)
+	}
+	return file.Sync()
+}
+
+// peek 返回最早的告警
+func (q *deadLetterQueue) peek() (*alert.Alert, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 {
+		return nil, false
+	}
+	return q.entries[0], true
+}
+
+// remove 移除已重放的告警，告警已因队列满被丢弃时不做处理
+func (q *deadLetterQueue) remove(a *alert.Alert) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 || q.entries[0] != a {
+		return nil
+	}
+	q.entries[0] = nil
+	q.entries = q.entries[1:]
+	return q.rewrite()
+}
+
+// len 队列中的告警数
+func (q *deadLetterQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// rewrite 用当前队列内容替换队列文件，先写临时文件再重命名保证原子性，调用方需持有锁
+func (q *deadLetterQueue) rewrite() error {
+	if len(q.entries) == 0 {
+		if err := os.Remove(q.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除死信队列失败: %v", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, a := range q.entries {
+		data, err := json.Marshal(a)
+		if err != nil {
+			return fmt.Errorf("序列化告警失败: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := fileutil.WriteAtomic(q.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("写入死信队列失败: %v", err)
+	}
+	return nil
+}
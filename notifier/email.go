@@ -0,0 +1,274 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const (
+	defaultEmailSubject = "{{.Title}} {{.File}}" // 默认邮件主题模板
+	smtpTimeout         = 30 * time.Second       // 单次发送的超时时间
+)
+
+// EmailNotifier SMTP 邮件通知器
+type EmailNotifier struct {
+	cfg     config.SMTP
+	addr    string
+	html    bool // 是否同时发送 HTML 正文
+	subject *template.Template
+	tmpl    *template.Template
+}
+
+// NewEmailNotifier 创建邮件通知器
+func NewEmailNotifier(cfg config.Notifier, tmpl *template.Template) (*EmailNotifier, error) {
+	e := &EmailNotifier{
+		cfg:  *cfg.SMTP,
+		html: cfg.Format == "html",
+		tmpl: tmpl,
+	}
+
+	port := e.cfg.Port
+	if port == 0 {
+		port = 587
+		if e.cfg.TLS == "tls" {
+			port = 465
+		}
+	}
+	e.addr = net.JoinHostPort(e.cfg.Host, strconv.Itoa(port))
+
+	subject := e.cfg.Subject
+	if subject == "" {
+		subject = defaultEmailSubject
+	}
+	var err error
+	if e.subject, err = alert.ParseTemplate("subject", subject); err != nil {
+		return nil, fmt.Errorf("邮件主题模板无效: %v", err)
+	}
+	return e, nil
+}
+
+// Send 发送告警邮件
+func (e *EmailNotifier) Send(a *alert.Alert) error {
+	subject, err := alert.Render(e.subject, a)
+	if err != nil {
+		return err
+	}
+	text, err := alert.Render(e.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	msg, err := e.buildMessage(strings.TrimSpace(subject), text, a)
+	if err != nil {
+		return err
+	}
+	return smtpError(e.deliver(msg))
+}
+
+// deliver 连接 SMTP 服务器并投递邮件
+func (e *EmailNotifier) deliver(msg []byte) error {
+	tlsConfig := &tls.Config{
+		ServerName:         e.cfg.Host,
+		InsecureSkipVerify: e.cfg.InsecureSkipVerify,
+	}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if e.cfg.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", e.addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", e.addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.cfg.TLS == "" || e.cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP服务器不支持 STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(addressOf(e.cfg.From)); err != nil {
+		return err
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(addressOf(to)); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage 构建邮件内容，配置 html 格式时发送 text/html 双正文
+func (e *EmailNotifier) buildMessage(subject, text string, a *alert.Alert) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", e.cfg.From)
+	header("To", strings.Join(e.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if !e.html {
+		header("Content-Type", "text/plain; charset=UTF-8")
+		header("Content-Transfer-Encoding", "base64")
+		buf.WriteString("\r\n")
+		writeBase64(&buf, text)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", emailHTML(a)},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("构建邮件失败: %v", err)
+		}
+		var part bytes.Buffer
+		writeBase64(&part, p.body)
+		pw.Write(part.Bytes())
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("构建邮件失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 以每行76个字符写入 base64 编码内容
+func writeBase64(buf *bytes.Buffer, text string) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+// emailHTML 构建 HTML 邮件正文
+func emailHTML(a *alert.Alert) string {
+	var b strings.Builder
+	b.WriteString(`<html><body style="font-family: sans-serif;">`)
+	b.WriteString(`<h3 style="color: ` + htmlColor(a) + `;">` + html.EscapeString(a.Title) + `</h3>`)
+	b.WriteString(`<table style="border-collapse: collapse;">`)
+	for _, d := range alertDetails(a) {
+		b.WriteString(`<tr><td style="padding: 2px 12px 2px 0; color: #666;">` + html.EscapeString(d.label) +
+			`</td><td style="padding: 2px 0;">` + html.EscapeString(d.value) + `</td></tr>`)
+	}
+	b.WriteString(`</table>`)
+	b.WriteString(`<pre style="background: #f5f5f5; padding: 8px; white-space: pre-wrap;">` + html.EscapeString(a.Line) + `</pre>`)
+	if len(a.Samples) > 0 {
+		b.WriteString(`<p>样例:</p><pre style="background: #f5f5f5; padding: 8px; white-space: pre-wrap;">` +
+			html.EscapeString(strings.Join(a.Samples, "\n")) + `</pre>`)
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
+
+// htmlColor HTML 邮件标题颜色，与飞书卡片的颜色规则一致
+func htmlColor(a *alert.Alert) string {
+	switch headerColor(a) {
+	case "green":
+		return "#2e7d32"
+	case "orange":
+		return "#ef6c00"
+	case "blue":
+		return "#1565c0"
+	default:
+		return "#c62828"
+	}
+}
+
+// addressOf 从 "名称 <地址>" 格式中取出邮件地址
+func addressOf(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Address
+	}
+	return s
+}
+
+// smtpError 对 SMTP 错误分类：4xx 为临时错误可以重试，535 为鉴权失败，其余 5xx 为被拒绝
+func smtpError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		kind := ErrContentRejected
+		switch {
+		case tpErr.Code >= 400 && tpErr.Code < 500:
+			kind = ErrServer
+		case tpErr.Code == 530 || tpErr.Code == 534 || tpErr.Code == 535:
+			kind = ErrAuth
+		}
+		return &SendError{Kind: kind, Code: tpErr.Code, Message: tpErr.Msg}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) {
+		return &SendError{Kind: ErrTransport, Message: fmt.Sprintf("连接SMTP服务器失败: %v", err)}
+	}
+	return &SendError{Kind: ErrUnknown, Message: fmt.Sprintf("发送邮件失败: %v", err)}
+}
@@ -0,0 +1,302 @@
+package notifier
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+// smtpSession 测试 SMTP 服务器收到的一次投递
+type smtpSession struct {
+	from string
+	rcpt []string
+	data string
+}
+
+// fakeSMTPServer 只支持明文连接的最小 SMTP 服务器
+type fakeSMTPServer struct {
+	listener  net.Listener
+	rcptReply string // RCPT 命令的响应，为空时接受所有收件人
+
+	mu       sync.Mutex
+	sessions []smtpSession
+	wg       sync.WaitGroup
+}
+
+// newFakeSMTPServer 在本机随机端口启动测试 SMTP 服务器
+func newFakeSMTPServer(t *testing.T, rcptReply string) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听端口失败: %v", err)
+	}
+	s := &fakeSMTPServer{listener: listener, rcptReply: rcptReply}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+
+	var session smtpSession
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "MAIL":
+			session.from = pathOf(line)
+			reply("250 OK")
+		case "RCPT":
+			if s.rcptReply != "" {
+				reply(s.rcptReply)
+				continue
+			}
+			session.rcpt = append(session.rcpt, pathOf(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			session.data = data.String()
+			s.mu.Lock()
+			s.sessions = append(s.sessions, session)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// pathOf 取出 MAIL FROM/RCPT TO 命令中尖括号内的地址
+func pathOf(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.Index(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// received 已完成的投递
+func (s *fakeSMTPServer) received() []smtpSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpSession(nil), s.sessions...)
+}
+
+// newTestEmailNotifier 创建连接到测试服务器的邮件通知器
+func newTestEmailNotifier(t *testing.T, server *fakeSMTPServer, format string) *EmailNotifier {
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+
+	tmpl, err := alert.ParseTemplate("message", alert.DefaultTemplate)
+	if err != nil {
+		t.Fatalf("解析消息模板失败: %v", err)
+	}
+	e, err := NewEmailNotifier(config.Notifier{
+		Type:   "email",
+		Format: format,
+		SMTP: &config.SMTP{
+			Host: host,
+			Port: portNum,
+			TLS:  "none",
+			From: "日志哨兵 <alert@example.com>",
+			To:   []string{"ops@example.com", "Manager <manager@example.com>"},
+		},
+	}, tmpl)
+	if err != nil {
+		t.Fatalf("创建邮件通知器失败: %v", err)
+	}
+	return e
+}
+
+// decodePart 解码 base64 编码的邮件正文
+func decodePart(t *testing.T, r io.Reader) string {
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+	if err != nil {
+		t.Fatalf("解码邮件正文失败: %v", err)
+	}
+	return string(data)
+}
+
+func TestEmailSendPlainText(t *testing.T) {
+	server := newFakeSMTPServer(t, "")
+	e := newTestEmailNotifier(t, server, "")
+
+	a := alert.Sample()
+	if err := e.Send(a); err != nil {
+		t.Fatalf("发送邮件失败: %v", err)
+	}
+
+	sessions := server.received()
+	if len(sessions) != 1 {
+		t.Fatalf("收到 %d 封邮件，期望 1 封", len(sessions))
+	}
+	session := sessions[0]
+	if session.from != "alert@example.com" {
+		t.Errorf("发件人 = %q，期望 alert@example.com", session.from)
+	}
+	if want := []string{"ops@example.com", "manager@example.com"}; strings.Join(session.rcpt, ",") != strings.Join(want, ",") {
+		t.Errorf("收件人 = %v，期望 %v", session.rcpt, want)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	if got := msg.Header.Get("To"); got != "ops@example.com, Manager <manager@example.com>" {
+		t.Errorf("To = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != a.Title+" "+a.File {
+		t.Errorf("Subject = %q，期望 %q", subject, a.Title+" "+a.File)
+	}
+	if got := msg.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q，期望 text/plain", got)
+	}
+	if body := decodePart(t, msg.Body); !strings.Contains(body, a.Line) {
+		t.Errorf("正文不包含日志内容: %q", body)
+	}
+}
+
+func TestEmailSendHTML(t *testing.T) {
+	server := newFakeSMTPServer(t, "")
+	e := newTestEmailNotifier(t, server, "html")
+
+	a := alert.Sample()
+	a.Line = `<script>alert("x")</script>`
+	if err := e.Send(a); err != nil {
+		t.Fatalf("发送邮件失败: %v", err)
+	}
+
+	sessions := server.received()
+	if len(sessions) != 1 {
+		t.Fatalf("收到 %d 封邮件，期望 1 封", len(sessions))
+	}
+	if len(sessions[0].rcpt) != 2 {
+		t.Errorf("收件人 = %v，期望 2 个", sessions[0].rcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(sessions[0].data))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q，期望 multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	parts := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("解析邮件正文失败: %v", err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = decodePart(t, part)
+	}
+
+	if !strings.Contains(parts["text/plain"], a.Line) {
+		t.Errorf("纯文本正文不包含日志内容: %q", parts["text/plain"])
+	}
+	html := parts["text/html"]
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Errorf("HTML 正文未转义日志内容: %q", html)
+	}
+	if !strings.Contains(html, htmlColor(a)) {
+		t.Errorf("HTML 标题颜色应为 %s", htmlColor(a))
+	}
+}
+
+func TestEmailSendErrorKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		rcptReply string
+		wantKind  ErrorKind
+		retryable bool
+	}{
+		{"临时错误可以重试", "451 Temporary local problem", ErrServer, true},
+		{"收件人被拒绝", "550 No such user", ErrContentRejected, false},
+		{"需要认证", "530 Authentication required", ErrAuth, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, tt.rcptReply)
+			e := newTestEmailNotifier(t, server, "")
+
+			err := e.Send(alert.Sample())
+			var sendErr *SendError
+			if !errors.As(err, &sendErr) {
+				t.Fatalf("错误类型 = %T，期望 *SendError", err)
+			}
+			if sendErr.Kind != tt.wantKind || sendErr.Retryable() != tt.retryable {
+				t.Errorf("错误分类 = %v (可重试: %v)，期望 %v (可重试: %v)", sendErr.Kind, sendErr.Retryable(), tt.wantKind, tt.retryable)
+			}
+			if len(server.received()) != 0 {
+				t.Errorf("收件人被拒绝时不应投递邮件")
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind 发送错误分类，决定是否重试以及日志提示
+type ErrorKind string
+
+const (
+	ErrTransport       ErrorKind = "transport"        // 网络错误、超时
+	ErrServer          ErrorKind = "server"           // 平台服务端错误（5xx）
+	ErrRateLimited     ErrorKind = "rate_limited"     // 发送过于频繁被限流
+	ErrAuth            ErrorKind = "auth"             // webhook 无效、签名校验失败、IP 不在白名单
+	ErrContentRejected ErrorKind = "content_rejected" // 不包含自定义关键词、消息格式错误等
+	ErrUnknown         ErrorKind = "unknown"          // 其他平台错误
+)
+
+// describe 错误分类说明
+func (k ErrorKind) describe() string {
+	switch k {
+	case ErrTransport:
+		return "网络错误"
+	case ErrServer:
+		return "服务端错误"
+	case ErrRateLimited:
+		return "发送过于频繁"
+	case ErrAuth:
+		return "鉴权失败"
+	case ErrContentRejected:
+		return "消息被拒绝"
+	default:
+		return "发送失败"
+	}
+}
+
+// SendError 通知发送失败的错误
+type SendError struct {
+	Kind    ErrorKind
+	Code    int    // 平台返回的错误码，HTTP 错误时为状态码
+	Message string // 错误信息
+}
+
+func (e *SendError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("%s（%d）: %s", e.Kind.describe(), e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Kind.describe(), e.Message)
+}
+
+// Retryable 稍后重试是否可能成功：网络错误、服务端错误和限流可以重试
+func (e *SendError) Retryable() bool {
+	return e.Kind == ErrTransport || e.Kind == ErrServer || e.Kind == ErrRateLimited
+}
+
+// errorKind 返回错误分类，非 SendError 返回空字符串
+func errorKind(err error) ErrorKind {
+	var se *SendError
+	if errors.As(err, &se) {
+		return se.Kind
+	}
+	return ""
+}
+
+// isRetryable 判断错误是否可以重试
+func isRetryable(err error) bool {
+	var se *SendError
+	return errors.As(err, &se) && se.Retryable()
+}
+
+// transportError 网络错误
+func transportError(err error) error {
+	return &SendError{Kind: ErrTransport, Message: fmt.Sprintf("发送HTTP请求失败: %v", err)}
+}
+
+// statusError 根据HTTP状态码构造发送错误
+func statusError(code int, body []byte) error {
+	kind := ErrUnknown
+	switch {
+	case code == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		kind = ErrAuth
+	case code >= 500:
+		kind = ErrServer
+	}
+
+	message := "HTTP请求失败"
+	if text := strings.TrimSpace(string(body)); text != "" {
+		message += ": " + text
+	}
+	return &SendError{Kind: kind, Code: code, Message: message}
+}
+
+// readResponse 读取响应内容，非 200 状态码直接返回错误
+func readResponse(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp.StatusCode, body)
+	}
+	if err != nil {
+		return nil, transportError(err)
+	}
+	return body, nil
+}
+
+// feishuResponse 飞书机器人响应，旧版接口使用 StatusCode/StatusMessage
+type feishuResponse struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+// checkFeishuResponse 检查飞书响应中的错误码
+func checkFeishuResponse(body []byte) error {
+	var resp feishuResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		// 无法解析时以 HTTP 状态码为准
+		return nil
+	}
+
+	code, msg := resp.Code, resp.Msg
+	if code == 0 {
+		code, msg = resp.StatusCode, resp.StatusMessage
+	}
+	if code == 0 {
+		return nil
+	}
+
+	kind := ErrUnknown
+	switch code {
+	case 19001, 19021, 19022: // access token 无效、签名校验失败、IP 不在白名单
+		kind = ErrAuth
+	case 11232: // 发送频率超限
+		kind = ErrRateLimited
+	case 9499, 19002, 19024: // 请求格式错误、消息类型错误、不包含自定义关键词
+		kind = ErrContentRejected
+	}
+	return &SendError{Kind: kind, Code: code, Message: msg}
+}
+
+// dingtalkResponse 钉钉机器人响应
+type dingtalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// checkDingtalkResponse 检查钉钉响应中的错误码
+func checkDingtalkResponse(body []byte) error {
+	var resp dingtalkResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.ErrCode == 0 {
+		return nil
+	}
+
+	kind := ErrUnknown
+	switch resp.ErrCode {
+	case 300001, 300005, 400101: // token 不存在、无效或机器人已停用
+		kind = ErrAuth
+	case 130101, 410100: // 发送频率超限（每分钟20条）
+		kind = ErrRateLimited
+	case 40035, 43004: // 缺少参数、Content-Type 错误
+		kind = ErrContentRejected
+	case 310000: // 安全设置校验失败，具体原因见错误信息
+		msg := strings.ToLower(resp.ErrMsg)
+		if strings.Contains(msg, "keyword") {
+			kind = ErrContentRejected
+		} else {
+			// 签名不匹配、时间戳无效、IP 不在白名单
+			kind = ErrAuth
+		}
+	}
+	return &SendError{Kind: kind, Code: resp.ErrCode, Message: resp.ErrMsg}
+}
+
+// wecomResponse 企业微信机器人响应
+type wecomResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// checkWecomResponse 检查企业微信响应中的错误码
+func checkWecomResponse(body []byte) error {
+	var resp wecomResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.ErrCode == 0 {
+		return nil
+	}
+
+	kind := ErrUnknown
+	switch resp.ErrCode {
+	case 93000, 40014, 42001: // webhook key 无效或已失效
+		kind = ErrAuth
+	case 45009, 45033: // 发送频率超限（每分钟20条）
+		kind = ErrRateLimited
+	case 40008, 40035, 44004, 45002, 40058: // 消息类型错误、参数错误、内容为空、内容过长
+		kind = ErrContentRejected
+	}
+	return &SendError{Kind: kind, Code: resp.ErrCode, Message: resp.ErrMsg}
+}
+
+// checkSlackResponse 检查 Slack 响应，Slack 通过 HTTP 状态码和纯文本错误码返回错误
+func checkSlackResponse(code int, body []byte) error {
+	if code >= 200 && code < 300 {
+		return nil
+	}
+
+	msg := strings.TrimSpace(string(body))
+	kind := ErrUnknown
+	switch {
+	case code == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case code >= 500:
+		kind = ErrServer
+	case code == http.StatusBadRequest: // invalid_payload、no_text、invalid_blocks 等
+		kind = ErrContentRejected
+	case code == http.StatusForbidden || code == http.StatusNotFound || code == http.StatusGone:
+		// invalid_token、no_service（webhook 已撤销）、channel_is_archived 等
+		kind = ErrAuth
+	}
+	return &SendError{Kind: kind, Code: code, Message: msg}
+}
+
+// checkTeamsResponse 检查 Teams 响应，旧版连接器在 HTTP 200 的响应内容中返回限流等错误
+func checkTeamsResponse(code int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if code >= 200 && code < 300 {
+		if !strings.Contains(msg, "delivery failed") {
+			return nil
+		}
+		if strings.Contains(msg, "429") {
+			return &SendError{Kind: ErrRateLimited, Code: code, Message: msg}
+		}
+		return &SendError{Kind: ErrUnknown, Code: code, Message: msg}
+	}
+	if code == http.StatusBadRequest {
+		return &SendError{Kind: ErrContentRejected, Code: code, Message: msg}
+	}
+	if code == http.StatusNotFound || code == http.StatusGone {
+		return &SendError{Kind: ErrAuth, Code: code, Message: msg}
+	}
+	return statusError(code, body)
+}
+
+// telegramResponse Telegram Bot API 响应
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+// checkTelegramResponse 检查 Telegram 响应，错误码与 HTTP 状态码一致
+func checkTelegramResponse(code int, body []byte) error {
+	var resp telegramResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		if code >= 200 && code < 300 {
+			return nil
+		}
+		return statusError(code, body)
+	}
+	if resp.OK {
+		return nil
+	}
+
+	if resp.ErrorCode == 0 {
+		resp.ErrorCode = code
+	}
+	kind := ErrUnknown
+	switch {
+	case resp.ErrorCode == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	case resp.ErrorCode >= 500:
+		kind = ErrServer
+	case resp.ErrorCode == http.StatusUnauthorized || resp.ErrorCode == http.StatusForbidden ||
+		resp.ErrorCode == http.StatusNotFound:
+		// token 无效、机器人被移出群组
+		kind = ErrAuth
+	case strings.Contains(resp.Description, "chat not found"):
+		kind = ErrAuth
+	case resp.ErrorCode == http.StatusBadRequest: // 无法解析 MarkdownV2、消息过长等
+		kind = ErrContentRejected
+	}
+	return &SendError{Kind: kind, Code: resp.ErrorCode, Message: resp.Description}
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckPlatformResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		check     func(body []byte) error
+		body      string
+		wantKind  ErrorKind // 为空表示发送成功
+		retryable bool
+	}{
+		{"飞书成功", checkFeishuResponse, `{"code":0,"msg":"success"}`, "", false},
+		{"飞书旧版接口成功", checkFeishuResponse, `{"StatusCode":0,"StatusMessage":"success"}`, "", false},
+		{"飞书无法解析的响应", checkFeishuResponse, `ok`, "", false},
+		{"飞书签名校验失败", checkFeishuResponse, `{"code":19021,"msg":"sign match fail"}`, ErrAuth, false},
+		{"飞书 IP 不在白名单", checkFeishuResponse, `{"code":19022,"msg":"Ip Not Allowed"}`, ErrAuth, false},
+		{"飞书发送频率超限", checkFeishuResponse, `{"code":11232,"msg":"frequency limited"}`, ErrRateLimited, true},
+		{"飞书不包含自定义关键词", checkFeishuResponse, `{"code":19024,"msg":"Key Words Not Found"}`, ErrContentRejected, false},
+		{"飞书旧版接口错误码", checkFeishuResponse, `{"StatusCode":19001,"StatusMessage":"param invalid: incoming webhook access token invalid"}`, ErrAuth, false},
+		{"飞书未知错误码", checkFeishuResponse, `{"code":12345,"msg":"unknown"}`, ErrUnknown, false},
+		{"钉钉成功", checkDingtalkResponse, `{"errcode":0,"errmsg":"ok"}`, "", false},
+		{"钉钉 token 无效", checkDingtalkResponse, `{"errcode":300001,"errmsg":"token is not exist"}`, ErrAuth, false},
+		{"钉钉发送频率超限", checkDingtalkResponse, `{"errcode":410100,"errmsg":"send too fast"}`, ErrRateLimited, true},
+		{"钉钉缺少参数", checkDingtalkResponse, `{"errcode":40035,"errmsg":"缺少参数 json"}`, ErrContentRejected, false},
+		{"钉钉不包含自定义关键词", checkDingtalkResponse, `{"errcode":310000,"errmsg":"keywords not in content"}`, ErrContentRejected, false},
+		{"钉钉签名不匹配", checkDingtalkResponse, `{"errcode":310000,"errmsg":"sign not match"}`, ErrAuth, false},
+		{"钉钉未知错误码", checkDingtalkResponse, `{"errcode":88888,"errmsg":"unknown"}`, ErrUnknown, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check([]byte(tt.body))
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Errorf("期望发送成功，得到错误: %v", err)
+				}
+				return
+			}
+
+			var sendErr *SendError
+			if !errors.As(err, &sendErr) {
+				t.Fatalf("错误类型 = %T，期望 *SendError", err)
+			}
+			if sendErr.Kind != tt.wantKind || sendErr.Retryable() != tt.retryable {
+				t.Errorf("错误分类 = %v (可重试: %v)，期望 %v (可重试: %v)", sendErr.Kind, sendErr.Retryable(), tt.wantKind, tt.retryable)
+			}
+		})
+	}
+}
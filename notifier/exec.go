@@ -0,0 +1,163 @@
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const (
+	defaultExecTimeout = 30 * time.Second // 默认命令超时时间
+	maxExecOutput      = 64 * 1024        // 记录到日志的最大输出字节数
+)
+
+// envNameInvalid 环境变量名中不允许的字符
+var envNameInvalid = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// ExecNotifier 执行本地命令的通知器，告警以 JSON 写入标准输入，同时通过环境变量传递
+type ExecNotifier struct {
+	command []string
+	dir     string
+	timeout time.Duration
+	slots   chan struct{} // 并发限制
+
+	done      chan struct{} // 程序退出时关闭，不再等待执行
+	closeOnce sync.Once
+}
+
+// NewExecNotifier 创建命令通知器
+func NewExecNotifier(cfg config.Notifier) *ExecNotifier {
+	e := &ExecNotifier{
+		command: cfg.Exec.Command,
+		dir:     cfg.Exec.Dir,
+		timeout: cfg.Exec.Timeout,
+	}
+	if e.timeout <= 0 {
+		e.timeout = defaultExecTimeout
+	}
+	maxConcurrent := cfg.Exec.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	e.slots = make(chan struct{}, maxConcurrent)
+	e.done = make(chan struct{})
+	return e
+}
+
+// shutdown 程序退出时停止等待执行，排队中的告警返回可重试的错误，由重试通知器写入死信队列
+func (e *ExecNotifier) shutdown() {
+	e.closeOnce.Do(func() { close(e.done) })
+}
+
+// Send 执行命令，达到并发上限时等待正在执行的命令结束
+func (e *ExecNotifier) Send(a *alert.Alert) error {
+	input, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("序列化告警失败: %v", err)
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+	case <-e.done:
+		return &SendError{Kind: ErrTransport, Message: fmt.Sprintf("程序退出，命令 %s 未执行", e.command[0])}
+	}
+	defer func() { <-e.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Dir = e.dir
+	cmd.Env = append(os.Environ(), alertEnv(a)...)
+	cmd.Stdin = bytes.NewReader(input)
+	var output limitedBuffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// 命令超时被终止后，子进程仍持有输出管道时不再继续等待
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	e.logOutput(output.String())
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return &SendError{Kind: ErrUnknown, Message: fmt.Sprintf("命令 %s 执行超时（%v）", e.command[0], e.timeout)}
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &SendError{Kind: ErrUnknown, Code: exitErr.ExitCode(), Message: fmt.Sprintf("命令 %s 执行失败: %v", e.command[0], err)}
+		}
+		return &SendError{Kind: ErrUnknown, Message: fmt.Sprintf("启动命令 %s 失败: %v", e.command[0], err)}
+	}
+
+	log.Printf("命令 %s 执行成功，耗时 %v", e.command[0], time.Since(start).Truncate(time.Millisecond))
+	return nil
+}
+
+// logOutput 将命令输出逐行记录到日志
+func (e *ExecNotifier) logOutput(output string) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		log.Printf("命令 %s 输出: %s", e.command[0], scanner.Text())
+	}
+}
+
+// alertEnv 告警字段对应的环境变量，命名捕获组以 LOG_MONITOR_FIELD_<名称> 传递
+func alertEnv(a *alert.Alert) []string {
+	env := []string{
+		"LOG_MONITOR_KIND=" + a.Kind,
+		"LOG_MONITOR_TITLE=" + a.Title,
+		"LOG_MONITOR_FILE=" + a.File,
+		"LOG_MONITOR_HOSTNAME=" + a.Hostname,
+		"LOG_MONITOR_RULE=" + a.Rule,
+		"LOG_MONITOR_SEVERITY=" + a.Severity,
+		"LOG_MONITOR_KEYWORD=" + a.Keyword,
+		"LOG_MONITOR_LINE=" + a.Line,
+		"LOG_MONITOR_LINE_NUMBER=" + strconv.FormatInt(a.LineNumber, 10),
+		"LOG_MONITOR_TIME=" + a.Time.Format(time.RFC3339),
+		"LOG_MONITOR_SUMMARY=" + a.Summary,
+	}
+	for name, value := range a.Fields {
+		env = append(env, "LOG_MONITOR_FIELD_"+envNameInvalid.ReplaceAllString(strings.ToUpper(name), "_")+"="+value)
+	}
+	return env
+}
+
+// limitedBuffer 只保留前 maxExecOutput 字节的输出缓冲区
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := maxExecOutput - b.buf.Len(); remain < len(p) {
+		b.truncated = true
+		if remain > 0 {
+			b.buf.Write(p[:remain])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n...（输出过长已截断）"
+	}
+	return b.buf.String()
+}
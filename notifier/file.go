@@ -0,0 +1,263 @@
+package notifier
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const (
+	defaultFileMaxSizeMB  = 100 // 默认单个文件最大大小 (MB)
+	defaultFileMaxBackups = 10  // 默认保留的轮转文件数
+	fileBackupTimeFormat  = "20060102-150405.000"
+)
+
+// Recorder 记录告警及其投递结果的通知器
+// 发送告警前调用 Record，其他通知器发送完成（包括重试）后调用 RecordDeliveries，两条记录通过 id 关联
+type Recorder interface {
+	Notifier
+	Record(id string, a *alert.Alert) error
+	RecordDeliveries(id string, a *alert.Alert, results []DeliveryResult) error
+}
+
+// DeliveryResult 单个通知器的投递结果
+type DeliveryResult struct {
+	Notifier string `json:"notifier"`
+	OK       bool   `json:"ok"`
+	Silenced bool   `json:"silenced,omitempty"` // 告警屏蔽窗口内未发送
+	Error    string `json:"error,omitempty"`
+}
+
+// NewRecordID 生成告警记录ID
+func NewRecordID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// 审计记录类型
+const (
+	auditEventAlert     = "alert"     // 告警分发
+	auditEventDelivered = "delivered" // 投递完成
+)
+
+// auditRecord 审计日志中的告警记录
+type auditRecord struct {
+	ID         string            `json:"id"`
+	Event      string            `json:"event"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Kind       string            `json:"kind"`
+	Host       string            `json:"host"`
+	File       string            `json:"file"`
+	Rule       string            `json:"rule,omitempty"`
+	Severity   string            `json:"severity,omitempty"`
+	Keyword    string            `json:"keyword,omitempty"`
+	Line       string            `json:"line"`
+	LineNumber int64             `json:"line_number,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	Summary    string            `json:"summary,omitempty"`
+}
+
+// deliveryRecord 审计日志中的投递结果记录
+type deliveryRecord struct {
+	ID         string           `json:"id"`
+	Event      string           `json:"event"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Deliveries []DeliveryResult `json:"deliveries"`
+}
+
+// FileNotifier 将告警以 JSON 行追加到本地文件，按大小轮转
+type FileNotifier struct {
+	name        string
+	minSeverity string
+	path        string
+	maxSize     int64
+	maxBackups  int
+	maxAge      time.Duration
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileNotifier 创建文件通知器
+func NewFileNotifier(cfg config.Notifier) (*FileNotifier, error) {
+	f := &FileNotifier{
+		name:        cfg.RouteName(),
+		minSeverity: cfg.MinSeverity,
+		path:        cfg.File.Path,
+		maxSize:     int64(cfg.File.MaxSizeMB) * 1024 * 1024,
+		maxBackups:  cfg.File.MaxBackups,
+		maxAge:      cfg.File.MaxAge,
+	}
+	if f.maxSize <= 0 {
+		f.maxSize = defaultFileMaxSizeMB * 1024 * 1024
+	}
+	if f.maxBackups <= 0 {
+		f.maxBackups = defaultFileMaxBackups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return nil, fmt.Errorf("创建告警记录目录失败: %v", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Name 通知器名称
+func (f *FileNotifier) Name() string {
+	return f.name
+}
+
+// MinSeverity 记录的最低告警级别
+func (f *FileNotifier) MinSeverity() string {
+	return f.minSeverity
+}
+
+// Send 记录告警，不包含投递结果
+func (f *FileNotifier) Send(a *alert.Alert) error {
+	return f.Record(NewRecordID(), a)
+}
+
+// Record 记录分发的告警
+func (f *FileNotifier) Record(id string, a *alert.Alert) error {
+	return f.write(auditRecord{
+		ID:         id,
+		Event:      auditEventAlert,
+		Timestamp:  a.Time,
+		Kind:       a.Kind,
+		Host:       a.Hostname,
+		File:       a.File,
+		Rule:       a.Rule,
+		Severity:   a.Severity,
+		Keyword:    a.Keyword,
+		Line:       a.Line,
+		LineNumber: a.LineNumber,
+		Fields:     a.Fields,
+		Summary:    a.Summary,
+	})
+}
+
+// RecordDeliveries 记录告警的投递结果
+func (f *FileNotifier) RecordDeliveries(id string, a *alert.Alert, results []DeliveryResult) error {
+	if results == nil {
+		results = []DeliveryResult{}
+	}
+	return f.write(deliveryRecord{
+		ID:         id,
+		Event:      auditEventDelivered,
+		Timestamp:  time.Now(),
+		Deliveries: results,
+	})
+}
+
+// write 追加一条 JSON 记录
+func (f *FileNotifier) write(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化告警记录失败: %v", err)
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		// 轮转失败时继续写入当前文件，不丢弃审计记录
+		if err := f.rotate(); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("写入告警记录失败: %v", err)
+	}
+	// 审计记录要求持久化，每条记录都同步到磁盘
+	if err := f.file.Sync(); err != nil {
+		return fmt.Errorf("写入告警记录失败: %v", err)
+	}
+	return nil
+}
+
+// open 以追加方式打开记录文件
+func (f *FileNotifier) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开告警记录文件失败: %v", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("打开告警记录文件失败: %v", err)
+	}
+	f.file = file
+	f.size = stat.Size()
+	return nil
+}
+
+// rotate 将当前文件重命名为带时间戳的备份文件并打开新文件，然后清理过期的备份
+// 先重命名再关闭旧文件，任何一步失败时都保证仍有可写入的文件
+func (f *FileNotifier) rotate() error {
+	backup := f.path + "." + time.Now().Format(fileBackupTimeFormat)
+	// 记录文件不存在（上次轮转未能创建新文件，或被外部删除）时直接重新打开
+	if err := os.Rename(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("轮转告警记录文件失败: %v", err)
+	}
+
+	old := f.file
+	if err := f.open(); err != nil {
+		// 无法创建新文件，继续写入已重命名的旧文件
+		return fmt.Errorf("轮转告警记录文件失败: %v", err)
+	}
+	old.Close()
+	f.removeBackups()
+	return nil
+}
+
+// removeBackups 删除超出保留数量或保留时间的备份文件
+func (f *FileNotifier) removeBackups() {
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return
+	}
+
+	// 备份文件名包含时间戳，按名称排序即为时间顺序，新的在前
+	var valid []string
+	for _, backup := range backups {
+		suffix := strings.TrimPrefix(backup, f.path+".")
+		if _, err := time.Parse(fileBackupTimeFormat, suffix); err == nil {
+			valid = append(valid, backup)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(valid)))
+
+	for i, backup := range valid {
+		expired := false
+		if f.maxAge > 0 {
+			if stat, err := os.Stat(backup); err == nil && time.Since(stat.ModTime()) > f.maxAge {
+				expired = true
+			}
+		}
+		if i >= f.maxBackups || expired {
+			os.Remove(backup)
+		}
+	}
+}
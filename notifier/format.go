@@ -0,0 +1,198 @@
+package notifier
+
+import (
+	"strconv"
+	"strings"
+
+	"log-monitor/alert"
+)
+
+// 消息格式
+const (
+	FormatText     = "text"     // 纯文本，内容由消息模板渲染
+	FormatCard     = "card"     // 飞书交互式卡片 / 钉钉 actionCard
+	FormatMarkdown = "markdown" // 钉钉、企业微信 markdown，飞书使用卡片
+)
+
+// 飞书、钉钉请求体最大 20KB，日志内容和样例需要截断，为字段、模板和 JSON 转义预留空间
+const (
+	feishuContentLimit   = 8 * 1024 // 飞书消息中日志内容和样例的最大字节数
+	dingtalkContentLimit = 8 * 1024 // 钉钉消息中日志内容和样例的最大字节数
+)
+
+// limitContent 返回日志内容和样例合计不超过 limit 字节的告警副本，多行事件（如异常堆栈）可能远超平台限制
+func limitContent(a *alert.Alert, limit int) *alert.Alert {
+	size := len(a.Line)
+	for _, sample := range a.Samples {
+		size += len(sample)
+	}
+	if size <= limit {
+		return a
+	}
+
+	limited := *a
+	limited.Line = truncateBytes(a.Line, limit)
+	remain := limit - len(limited.Line)
+	limited.Samples = nil
+	for _, sample := range a.Samples {
+		if remain < 256 {
+			break
+		}
+		sample = truncateBytes(sample, remain)
+		limited.Samples = append(limited.Samples, sample)
+		remain -= len(sample)
+	}
+	return &limited
+}
+
+// detailField 卡片和 markdown 消息中展示的告警字段
+type detailField struct {
+	label string
+	value string
+}
+
+// alertDetails 告警的字段列表，只包含有值的字段
+func alertDetails(a *alert.Alert) []detailField {
+	details := []detailField{
+		{"文件", a.File},
+		{"主机", a.Hostname},
+		{"时间", a.Time.Format("2006-01-02 15:04:05")},
+	}
+	if a.Rule != "" {
+		details = append(details, detailField{"规则", a.Rule})
+	}
+	if a.Severity != "" {
+		details = append(details, detailField{"级别", a.Severity})
+	}
+	if a.LineNumber > 0 {
+		details = append(details, detailField{"行号", strconv.FormatInt(a.LineNumber, 10)})
+	}
+	if len(a.Fields) > 0 {
+		details = append(details, detailField{"字段", alert.FormatFields(a.Fields)})
+	}
+	if a.Summary != "" {
+		details = append(details, detailField{"次数", a.Summary})
+	}
+	return details
+}
+
+// headerColor 飞书卡片标题颜色，恢复通知为绿色，其余按告警级别：critical 为红色，warning 为橙色，info 为蓝色
+func headerColor(a *alert.Alert) string {
+	if a.Kind == alert.KindRecovery {
+		return "green"
+	}
+	switch a.Severity {
+	case alert.SeverityCritical:
+		return "red"
+	case alert.SeverityInfo:
+		return "blue"
+	default:
+		return "orange"
+	}
+}
+
+// codeBlock 将文本包装为 markdown 代码块
+func codeBlock(text string) string {
+	return "```\n" + strings.ReplaceAll(text, "```", "'''") + "\n```"
+}
+
+// shouldFallback 判断富文本消息失败后是否需要降级为文本消息
+// 鉴权失败、限流和网络错误换成文本消息也不会成功，交给重试处理
+func shouldFallback(err error) bool {
+	if err == nil {
+		return false
+	}
+	kind := errorKind(err)
+	return kind == ErrContentRejected || kind == ErrUnknown
+}
+
+// feishuCardPayload 构建飞书交互式卡片消息
+func feishuCardPayload(a *alert.Alert, mention *alert.Mention) map[string]interface{} {
+	var fields []map[string]interface{}
+	for _, d := range alertDetails(a) {
+		fields = append(fields, map[string]interface{}{
+			"is_short": true,
+			"text": map[string]string{
+				"tag":     "lark_md",
+				"content": "**" + d.label + "**\n" + d.value,
+			},
+		})
+	}
+
+	elements := []map[string]interface{}{
+		{"tag": "div", "fields": fields},
+		{"tag": "markdown", "content": codeBlock(a.Line)},
+	}
+	if len(a.Samples) > 0 {
+		elements = append(elements, map[string]interface{}{
+			"tag":     "markdown",
+			"content": "**样例**\n" + codeBlock(strings.Join(a.Samples, "\n")),
+		})
+	}
+	if !mention.Empty() {
+		elements = append(elements, map[string]interface{}{
+			"tag":     "markdown",
+			"content": feishuAtCard(mention),
+		})
+	}
+
+	return map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"template": headerColor(a),
+				"title": map[string]string{
+					"tag":     "plain_text",
+					"content": a.Title,
+				},
+			},
+			"elements": elements,
+		},
+	}
+}
+
+// markdownMessage 构建钉钉、企业微信 markdown 文本，日志内容以引用块展示
+func markdownMessage(a *alert.Alert) string {
+	var b strings.Builder
+	b.WriteString("### " + a.Title + "\n\n")
+	for _, d := range alertDetails(a) {
+		b.WriteString("- **" + d.label + "**: " + d.value + "\n")
+	}
+	b.WriteString("\n> " + strings.ReplaceAll(a.Line, "\n", "\n>\n> ") + "\n")
+	if len(a.Samples) > 0 {
+		b.WriteString("\n**样例**\n\n> " + strings.Join(a.Samples, "\n>\n> ") + "\n")
+	}
+	return b.String()
+}
+
+// dingtalkMarkdownPayload 构建钉钉 markdown 或 actionCard 消息，actionCard 不支持 @提醒
+func dingtalkMarkdownPayload(a *alert.Alert, format, cardURL string, mention *alert.Mention) map[string]interface{} {
+	if format == FormatCard {
+		return map[string]interface{}{
+			"msgtype": "actionCard",
+			"actionCard": map[string]string{
+				"title":       a.Title,
+				"text":        markdownMessage(a),
+				"singleTitle": "查看详情",
+				"singleURL":   cardURL,
+			},
+		}
+	}
+
+	text := markdownMessage(a)
+	if !mention.Empty() {
+		text = appendLine(text, dingtalkAtText(mention))
+	}
+	payload := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": a.Title,
+			"text":  text,
+		},
+	}
+	if !mention.Empty() {
+		payload["at"] = dingtalkAt(mention)
+	}
+	return payload
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+func TestLimitContent(t *testing.T) {
+	a := alert.Sample()
+	if got := limitContent(a, feishuContentLimit); got != a {
+		t.Errorf("未超出限制时应返回原告警")
+	}
+
+	a.Line = strings.Repeat("at com.example.Service.call(Service.java:42)\n", 1000)
+	a.Samples = []string{strings.Repeat("x", 4096), strings.Repeat("y", 4096)}
+	got := limitContent(a, feishuContentLimit)
+
+	size := len(got.Line)
+	for _, sample := range got.Samples {
+		size += len(sample)
+	}
+	if size > feishuContentLimit {
+		t.Errorf("截断后日志内容和样例共 %d 字节，超过 %d", size, feishuContentLimit)
+	}
+	if len(a.Line) != 45000 || len(a.Samples) != 2 {
+		t.Errorf("不应修改原告警")
+	}
+}
+
+func TestFeishuLongStackTraceFitsRequestLimit(t *testing.T) {
+	var bodySize int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodySize = len(body)
+		io.WriteString(w, `{"code":0,"msg":"success"}`)
+	}))
+	defer server.Close()
+
+	tmpl, err := alert.ParseTemplate("feishu", alert.DefaultTemplate)
+	if err != nil {
+		t.Fatalf("解析消息模板失败: %v", err)
+	}
+	f := NewFeishuNotifier(config.Notifier{Type: "feishu", Webhook: server.URL, Format: FormatCard}, tmpl)
+
+	a := alert.Sample()
+	// XML 内容中的 < > 在默认的 JSON 编码中会被转义为 6 个字节
+	a.Line = strings.Repeat("<error><code>500</code></error>\n", 2000)
+	if err := f.Send(a); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if bodySize > 20*1024 {
+		t.Errorf("请求体 %d 字节，超过飞书 20KB 的限制", bodySize)
+	}
+}
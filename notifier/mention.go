@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"strings"
+
+	"log-monitor/alert"
+)
+
+// mentionFor 告警的 @提醒，规则配置了 @提醒 时以规则为准，否则使用通知器的配置
+func mentionFor(a *alert.Alert, fallback *alert.Mention) *alert.Mention {
+	if a.Mention != nil {
+		return a.Mention
+	}
+	return fallback
+}
+
+// feishuAtText 飞书文本消息中的 @ 标签
+func feishuAtText(m *alert.Mention) string {
+	var tags []string
+	for _, id := range m.OpenIDs {
+		tags = append(tags, `<at user_id="`+id+`"></at>`)
+	}
+	if m.All {
+		tags = append(tags, `<at user_id="all">所有人</at>`)
+	}
+	return strings.Join(tags, " ")
+}
+
+// feishuAtCard 飞书卡片 lark_md 中的 @ 标签
+func feishuAtCard(m *alert.Mention) string {
+	var tags []string
+	for _, id := range m.OpenIDs {
+		tags = append(tags, "<at id="+id+"></at>")
+	}
+	if m.All {
+		tags = append(tags, "<at id=all></at>")
+	}
+	return strings.Join(tags, " ")
+}
+
+// dingtalkAtText 钉钉消息内容中的 @ 文本，被 @ 的手机号或 userId 需要出现在内容中才会高亮
+func dingtalkAtText(m *alert.Mention) string {
+	var tags []string
+	for _, mobile := range m.Mobiles {
+		tags = append(tags, "@"+mobile)
+	}
+	for _, id := range m.UserIDs {
+		tags = append(tags, "@"+id)
+	}
+	return strings.Join(tags, " ")
+}
+
+// dingtalkAt 钉钉消息的 at 参数
+func dingtalkAt(m *alert.Mention) map[string]interface{} {
+	return map[string]interface{}{
+		"atMobiles": m.Mobiles,
+		"atUserIds": m.UserIDs,
+		"isAtAll":   m.All,
+	}
+}
+
+// appendLine 在文本末尾追加一行，追加内容为空时不做处理
+func appendLine(text, line string) string {
+	if line == "" {
+		return text
+	}
+	return text + "\n" + line
+}
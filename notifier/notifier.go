@@ -7,51 +7,181 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"text/template"
 	"time"
 
+	"log-monitor/alert"
 	"log-monitor/config"
 )
 
 // Notifier 通知器接口
 type Notifier interface {
-	Send(message string) error
+	Send(a *alert.Alert) error
 }
 
-// CreateNotifiers 根据配置创建通知器
-func CreateNotifiers(configs []config.Notifier) []Notifier {
+// NameOf 通知器名称，用于投递记录
+func NameOf(n Notifier) string {
+	if named, ok := n.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// Accepts 告警级别是否达到通知器配置的最低级别
+func Accepts(n Notifier, a *alert.Alert) bool {
+	limited, ok := n.(interface{ MinSeverity() string })
+	if !ok || limited.MinSeverity() == "" {
+		return true
+	}
+	return alert.SeverityRank(a.Severity) >= alert.SeverityRank(limited.MinSeverity())
+}
+
+// Shutdown 程序退出时停止通知器的重试和死信队列重放，正在等待重试的告警写入死信队列
+func Shutdown(n Notifier) {
+	if closer, ok := n.(interface{ shutdown() }); ok {
+		closer.shutdown()
+	}
+}
+
+// marshalPayload 序列化请求体，不转义 <、>、&，避免 XML/HTML 日志内容膨胀数倍超过平台的请求体限制
+func marshalPayload(payload interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return nil, fmt.Errorf("序列化消息失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// httpClient 发送通知使用的HTTP客户端，避免请求无限期阻塞重试
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// CreateNotifiers 根据配置创建通知器，每个通知器都带有重试，配置了死信队列时最终失败的告警会写入队列
+func CreateNotifiers(c *config.Config) ([]Notifier, error) {
 	var notifiers []Notifier
 
-	for _, cfg := range configs {
+	defaultTemplate := c.Template
+	if defaultTemplate == "" {
+		defaultTemplate = alert.DefaultTemplate
+	}
+
+	policy := newRetryPolicy(c.Delivery)
+
+	for i, cfg := range c.Notifiers {
 		if !cfg.Enabled {
 			continue
 		}
 
+		text := cfg.Template
+		if text == "" {
+			text = defaultTemplate
+		}
+		tmpl, err := alert.ParseTemplate(cfg.Type, text)
+		if err != nil {
+			return nil, fmt.Errorf("通知器[%d]消息模板无效: %v", i, err)
+		}
+
+		// 文件通知器记录其他通知器的投递结果，不需要重试
+		if cfg.Type == "file" {
+			n, err := NewFileNotifier(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("通知器[%d]%v", i, err)
+			}
+			notifiers = append(notifiers, n)
+			continue
+		}
+
+		var n Notifier
 		switch cfg.Type {
 		case "feishu":
-			notifiers = append(notifiers, NewFeishuNotifier(cfg.Webhook))
+			n = NewFeishuNotifier(cfg, tmpl)
 		case "dingtalk":
-			notifiers = append(notifiers, NewDingtalkNotifier(cfg.Webhook, cfg.Secret))
+			n = NewDingtalkNotifier(cfg, tmpl)
+		case "wecom":
+			n = NewWecomNotifier(cfg, tmpl)
+		case "slack":
+			n = NewSlackNotifier(cfg, tmpl)
+		case "teams":
+			n = NewTeamsNotifier(cfg, tmpl)
+		case "telegram":
+			n = NewTelegramNotifier(cfg, tmpl)
+		case "webhook":
+			if n, err = NewWebhookNotifier(cfg); err != nil {
+				return nil, fmt.Errorf("通知器[%d]%v", i, err)
+			}
+		case "alertmanager":
+			n = NewAlertmanagerNotifier(cfg)
+		case "exec":
+			n = NewExecNotifier(cfg)
+		case "email":
+			if n, err = NewEmailNotifier(cfg, tmpl); err != nil {
+				return nil, fmt.Errorf("通知器[%d]%v", i, err)
+			}
+		default:
+			continue
 		}
+
+		var queue *deadLetterQueue
+		if c.Delivery != nil && c.Delivery.QueueDir != "" {
+			queue, err = openDeadLetterQueue(c.Delivery.QueueDir, queueName(cfg), c.Delivery.QueueMaxSize)
+			if err != nil {
+				return nil, fmt.Errorf("通知器[%d]%v", i, err)
+			}
+		}
+		r := newRetryNotifier(cfg.RouteName(), n, policy, queue, replayInterval(c.Delivery))
+		r.minSeverity = cfg.MinSeverity
+		notifiers = append(notifiers, r)
 	}
 
-	return notifiers
+	return notifiers, nil
 }
 
 // FeishuNotifier 飞书通知器
 type FeishuNotifier struct {
 	webhook string
+	secret  string
+	format  string         // 消息格式，card/markdown 发送交互式卡片
+	mention *alert.Mention // 默认 @提醒
+	tmpl    *template.Template
 }
 
 // NewFeishuNotifier 创建飞书通知器
-func NewFeishuNotifier(webhook string) *FeishuNotifier {
-	return &FeishuNotifier{webhook: webhook}
+func NewFeishuNotifier(cfg config.Notifier, tmpl *template.Template) *FeishuNotifier {
+	return &FeishuNotifier{
+		webhook: cfg.Webhook,
+		secret:  cfg.Secret,
+		format:  cfg.Format,
+		mention: cfg.Mention.AlertMention(),
+		tmpl:    tmpl,
+	}
 }
 
-// Send 发送飞书消息
-func (f *FeishuNotifier) Send(message string) error {
+// Send 发送飞书消息，卡片消息被拒绝时降级为文本消息
+func (f *FeishuNotifier) Send(a *alert.Alert) error {
+	a = limitContent(a, feishuContentLimit)
+	message, err := alert.Render(f.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	mention := mentionFor(a, f.mention)
+	if !mention.Empty() {
+		message = appendLine(message, feishuAtText(mention))
+	}
+
+	if f.format == FormatCard || f.format == FormatMarkdown {
+		err := f.sendHTTPRequest(feishuCardPayload(a, mention))
+		if !shouldFallback(err) {
+			return err
+		}
+		log.Printf("飞书卡片消息被拒绝，降级为文本消息: %v", err)
+	}
+
 	payload := map[string]interface{}{
 		"msg_type": "text",
 		"content": map[string]string{
@@ -63,56 +193,101 @@ func (f *FeishuNotifier) Send(message string) error {
 }
 
 // sendHTTPRequest 发送HTTP请求
-func (f *FeishuNotifier) sendHTTPRequest(payload interface{}) error {
-	jsonData, err := json.Marshal(payload)
+func (f *FeishuNotifier) sendHTTPRequest(payload map[string]interface{}) error {
+	// 开启签名校验的机器人需要在请求体中携带时间戳和签名
+	if f.secret != "" {
+		timestamp := time.Now().Unix()
+		payload["timestamp"] = strconv.FormatInt(timestamp, 10)
+		payload["sign"] = f.generateSign(timestamp)
+	}
+
+	jsonData, err := marshalPayload(payload)
 	if err != nil {
-		return fmt.Errorf("序列化消息失败: %v", err)
+		return err
 	}
 
-	resp, err := http.Post(f.webhook, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := httpClient.Post(f.webhook, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("发送HTTP请求失败: %v", err)
+		return transportError(err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
+	// 平台对鉴权失败、限流等错误同样返回 200，需要检查响应中的错误码
+	body, err := readResponse(resp)
+	if err != nil {
+		return err
 	}
+	return checkFeishuResponse(body)
+}
 
-	return nil
+// generateSign 生成飞书签名：以 "timestamp\nsecret" 为密钥对空字符串做 HmacSHA256
+func (f *FeishuNotifier) generateSign(timestamp int64) string {
+	stringToSign := strconv.FormatInt(timestamp, 10) + "\n" + f.secret
+	h := hmac.New(sha256.New, []byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
 // DingtalkNotifier 钉钉通知器
 type DingtalkNotifier struct {
 	webhook string
 	secret  string
+	format  string         // 消息格式，markdown 或 card（actionCard）
+	cardURL string         // actionCard 按钮跳转链接
+	mention *alert.Mention // 默认 @提醒
+	tmpl    *template.Template
 }
 
 // NewDingtalkNotifier 创建钉钉通知器
-func NewDingtalkNotifier(webhook, secret string) *DingtalkNotifier {
+func NewDingtalkNotifier(cfg config.Notifier, tmpl *template.Template) *DingtalkNotifier {
 	return &DingtalkNotifier{
-		webhook: webhook,
-		secret:  secret,
+		webhook: cfg.Webhook,
+		secret:  cfg.Secret,
+		format:  cfg.Format,
+		cardURL: cfg.CardURL,
+		mention: cfg.Mention.AlertMention(),
+		tmpl:    tmpl,
 	}
 }
 
-// Send 发送钉钉消息
-func (d *DingtalkNotifier) Send(message string) error {
+// Send 发送钉钉消息，markdown/actionCard 消息被拒绝时降级为文本消息
+func (d *DingtalkNotifier) Send(a *alert.Alert) error {
+	a = limitContent(a, dingtalkContentLimit)
+	message, err := alert.Render(d.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	mention := mentionFor(a, d.mention)
+
+	if d.format == FormatCard || d.format == FormatMarkdown {
+		err := d.sendHTTPRequest(dingtalkMarkdownPayload(a, d.format, d.cardURL, mention))
+		if !shouldFallback(err) {
+			return err
+		}
+		log.Printf("钉钉%s消息被拒绝，降级为文本消息: %v", d.format, err)
+	}
+
+	if !mention.Empty() {
+		message = appendLine(message, dingtalkAtText(mention))
+	}
 	payload := map[string]interface{}{
 		"msgtype": "text",
 		"text": map[string]string{
 			"content": message,
 		},
 	}
+	if !mention.Empty() {
+		payload["at"] = dingtalkAt(mention)
+	}
 
 	return d.sendHTTPRequest(payload)
 }
 
 // sendHTTPRequest 发送HTTP请求（带签名）
-func (d *DingtalkNotifier) sendHTTPRequest(payload interface{}) error {
-	jsonData, err := json.Marshal(payload)
+func (d *DingtalkNotifier) sendHTTPRequest(payload map[string]interface{}) error {
+	jsonData, err := marshalPayload(payload)
 	if err != nil {
-		return fmt.Errorf("序列化消息失败: %v", err)
+		return err
 	}
 
 	// 构建请求URL（如果有密钥则添加签名）
@@ -123,17 +298,18 @@ func (d *DingtalkNotifier) sendHTTPRequest(payload interface{}) error {
 		requestURL = fmt.Sprintf("%s&timestamp=%d&sign=%s", d.webhook, timestamp, url.QueryEscape(sign))
 	}
 
-	resp, err := http.Post(requestURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := httpClient.Post(requestURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("发送HTTP请求失败: %v", err)
+		return transportError(err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
+	// 平台对鉴权失败、限流等错误同样返回 200，需要检查响应中的错误码
+	body, err := readResponse(resp)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return checkDingtalkResponse(body)
 }
 
 // generateSign 生成钉钉签名
@@ -142,4 +318,4 @@ func (d *DingtalkNotifier) generateSign(timestamp int64) string {
 	h := hmac.New(sha256.New, []byte(d.secret))
 	h.Write([]byte(stringToSign))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
-}
\ No newline at end of file
+}
@@ -0,0 +1,225 @@
+package notifier
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const (
+	defaultMaxAttempts    = 3                // 默认最大发送次数
+	defaultInitialBackoff = 1 * time.Second  // 默认首次重试等待时间
+	defaultMaxBackoff     = 30 * time.Second // 默认最大重试等待时间
+	defaultReplayInterval = 1 * time.Minute  // 默认死信队列重放间隔
+)
+
+// retryPolicy 指数退避重试策略
+type retryPolicy struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// newRetryPolicy 根据配置创建重试策略，未配置的项使用默认值
+func newRetryPolicy(d *config.Delivery) retryPolicy {
+	p := retryPolicy{
+		maxAttempts:    defaultMaxAttempts,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+	}
+	if d == nil {
+		return p
+	}
+	if d.MaxAttempts > 0 {
+		p.maxAttempts = d.MaxAttempts
+	}
+	if d.InitialBackoff > 0 {
+		p.initialBackoff = d.InitialBackoff
+	}
+	if d.MaxBackoff > 0 {
+		p.maxBackoff = d.MaxBackoff
+	}
+	if p.initialBackoff > p.maxBackoff {
+		p.maxBackoff = p.initialBackoff
+	}
+	return p
+}
+
+// backoff 第 attempt 次失败后的等待时间，在 [d/2, d] 之间随机抖动，避免多个实例同时重试
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.maxBackoff
+	if attempt <= 30 {
+		if exp := p.initialBackoff << (attempt - 1); exp > 0 && exp < d {
+			d = exp
+		}
+	}
+	return jitter(d)
+}
+
+// rateLimitBackoff 被限流后的等待时间，平台按分钟限流，直接使用最大等待时间
+func (p retryPolicy) rateLimitBackoff() time.Duration {
+	return jitter(p.maxBackoff)
+}
+
+// jitter 在 [d/2, d] 之间随机取值
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// replayInterval 死信队列重放间隔
+func replayInterval(d *config.Delivery) time.Duration {
+	if d != nil && d.ReplayInterval > 0 {
+		return d.ReplayInterval
+	}
+	return defaultReplayInterval
+}
+
+// retryNotifier 为通知器增加重试和死信队列
+type retryNotifier struct {
+	name        string
+	minSeverity string // 发送的最低告警级别，为空时发送所有告警
+	next        Notifier
+	policy      retryPolicy
+	queue       *deadLetterQueue // 未配置死信队列时为nil
+	wake        chan struct{}    // 发送成功后触发重放
+	done        chan struct{}    // 程序退出时关闭，停止重试和重放
+	closeOnce   sync.Once
+}
+
+// newRetryNotifier 创建带重试的通知器，配置了死信队列时启动重放任务
+func newRetryNotifier(name string, next Notifier, policy retryPolicy, queue *deadLetterQueue, interval time.Duration) *retryNotifier {
+	r := &retryNotifier{
+		name:   name,
+		next:   next,
+		policy: policy,
+		queue:  queue,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if queue != nil {
+		if n := queue.len(); n > 0 {
+			log.Printf("%s 死信队列中有 %d 条待重放的告警", name, n)
+		}
+		go r.replayLoop(interval)
+	}
+	return r
+}
+
+// Name 通知器名称
+func (r *retryNotifier) Name() string {
+	return r.name
+}
+
+// MinSeverity 发送的最低告警级别
+func (r *retryNotifier) MinSeverity() string {
+	return r.minSeverity
+}
+
+// Send 发送告警，可重试的错误按退避策略重试，最终失败时写入死信队列
+func (r *retryNotifier) Send(a *alert.Alert) error {
+	attempts, err := r.sendWithRetry(a)
+	if err == nil {
+		// 通知器已恢复，尽快重放积压的告警
+		if r.queue != nil && r.queue.len() > 0 {
+			select {
+			case r.wake <- struct{}{}:
+			default:
+			}
+		}
+		return nil
+	}
+
+	if errorKind(err) == ErrAuth {
+		log.Printf("%s 鉴权失败，请检查 webhook、密钥和 IP 白名单配置", r.name)
+	}
+	if r.queue == nil || !isRetryable(err) {
+		return fmt.Errorf("%s 通知发送失败（尝试 %d 次）: %v", r.name, attempts, err)
+	}
+	if qerr := r.queue.push(a); qerr != nil {
+		return fmt.Errorf("%s 通知发送失败（尝试 %d 次）: %v，写入死信队列失败: %v", r.name, attempts, err, qerr)
+	}
+	return fmt.Errorf("%s 通知发送失败（尝试 %d 次），已写入死信队列: %v", r.name, attempts, err)
+}
+
+// shutdown 停止重试和重放，并通知被包装的通知器停止等待
+func (r *retryNotifier) shutdown() {
+	r.closeOnce.Do(func() { close(r.done) })
+	Shutdown(r.next)
+}
+
+// sendWithRetry 发送告警，返回尝试次数，程序退出时不再等待重试，返回最后一次的错误
+func (r *retryNotifier) sendWithRetry(a *alert.Alert) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := r.next.Send(a)
+		if err == nil || !isRetryable(err) || attempt >= r.policy.maxAttempts {
+			return attempt, err
+		}
+
+		delay := r.policy.backoff(attempt)
+		if errorKind(err) == ErrRateLimited {
+			delay = r.policy.rateLimitBackoff()
+		}
+		log.Printf("%s 通知发送失败（第 %d 次），%v 后重试: %v", r.name, attempt, delay.Truncate(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-r.done:
+			timer.Stop()
+			log.Printf("%s 程序退出，停止重试", r.name)
+			return attempt, err
+		}
+	}
+}
+
+// replayLoop 定期或在发送成功后重放死信队列
+func (r *retryNotifier) replayLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.wake:
+		case <-r.done:
+			return
+		}
+		r.replay()
+	}
+}
+
+// replay 按入队顺序重放死信队列，遇到可重试的错误时停止，等待下次重放
+func (r *retryNotifier) replay() {
+	replayed := 0
+	for {
+		a, ok := r.queue.peek()
+		if !ok {
+			break
+		}
+
+		if err := r.next.Send(a); err != nil {
+			if isRetryable(err) {
+				break
+			}
+			// 不可重试的错误（如请求被拒绝）重放也不会成功，直接丢弃
+			log.Printf("%s 重放告警失败，已丢弃: %v", r.name, err)
+		} else {
+			replayed++
+		}
+
+		if err := r.queue.remove(a); err != nil {
+			log.Printf("%s 更新死信队列失败: %v", r.name, err)
+			break
+		}
+	}
+
+	if replayed > 0 {
+		log.Printf("%s 已重放 %d 条告警，死信队列剩余 %d 条", r.name, replayed, r.queue.len())
+	}
+}
@@ -0,0 +1,152 @@
+package notifier
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+// testPolicy 测试使用的重试策略，避免等待
+var testPolicy = retryPolicy{maxAttempts: 3, initialBackoff: time.Millisecond, maxBackoff: 2 * time.Millisecond}
+
+// feishuServer 按顺序返回预设响应的飞书测试服务器，响应用完后返回成功
+type feishuServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses []int
+	requests  int
+}
+
+func newFeishuServer(t *testing.T, responses ...int) *feishuServer {
+	s := &feishuServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+
+		s.mu.Lock()
+		s.requests++
+		code := http.StatusOK
+		if len(s.responses) > 0 {
+			code, s.responses = s.responses[0], s.responses[1:]
+		}
+		s.mu.Unlock()
+
+		switch code {
+		case http.StatusOK:
+			io.WriteString(w, `{"code":0,"msg":"success"}`)
+		case 19021: // 签名校验失败时飞书返回 HTTP 200
+			io.WriteString(w, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`)
+		default:
+			w.WriteHeader(code)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// received 服务器收到的请求数
+func (s *feishuServer) received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// newTestFeishuNotifier 创建发送到测试服务器的飞书通知器
+func newTestFeishuNotifier(t *testing.T, url string) *FeishuNotifier {
+	tmpl, err := alert.ParseTemplate("feishu", alert.DefaultTemplate)
+	if err != nil {
+		t.Fatalf("解析消息模板失败: %v", err)
+	}
+	return NewFeishuNotifier(config.Notifier{Type: "feishu", Webhook: url}, tmpl)
+}
+
+func TestRetryNotifierSend(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []int
+		wantRequests int
+		wantErr      bool
+		wantQueued   int
+	}{
+		{"首次成功", nil, 1, false, 0},
+		{"服务端错误后重试成功", []int{http.StatusServiceUnavailable}, 2, false, 0},
+		{"限流后重试成功", []int{http.StatusTooManyRequests}, 2, false, 0},
+		{"超过最大次数写入死信队列", []int{500, 502, 503}, 3, true, 1},
+		{"鉴权失败不重试也不写入死信队列", []int{19021}, 1, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFeishuServer(t, tt.responses...)
+			queue, err := openDeadLetterQueue(t.TempDir(), "feishu.jsonl", 0)
+			if err != nil {
+				t.Fatalf("打开死信队列失败: %v", err)
+			}
+			r := newRetryNotifier("feishu", newTestFeishuNotifier(t, server.URL), testPolicy, queue, time.Hour)
+			defer r.shutdown()
+
+			err = r.Send(alert.Sample())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() 错误 = %v，期望出错: %v", err, tt.wantErr)
+			}
+			if got := server.received(); got != tt.wantRequests {
+				t.Errorf("发送 %d 次，期望 %d 次", got, tt.wantRequests)
+			}
+			if got := queue.len(); got != tt.wantQueued {
+				t.Errorf("死信队列中有 %d 条告警，期望 %d 条", got, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestDeadLetterQueueReplayAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	queue, err := openDeadLetterQueue(dir, "feishu.jsonl", 0)
+	if err != nil {
+		t.Fatalf("打开死信队列失败: %v", err)
+	}
+	for _, line := range []string{"ERROR first", "ERROR second"} {
+		a := alert.Sample()
+		a.Line = line
+		if err := queue.push(a); err != nil {
+			t.Fatalf("写入死信队列失败: %v", err)
+		}
+	}
+
+	// 模拟重启后重新打开队列
+	queue, err = openDeadLetterQueue(dir, "feishu.jsonl", 0)
+	if err != nil {
+		t.Fatalf("重新打开死信队列失败: %v", err)
+	}
+	if a, ok := queue.peek(); queue.len() != 2 || !ok || a.Line != "ERROR first" {
+		t.Fatalf("重新打开后队列中有 %d 条告警，期望按入队顺序保留 2 条", queue.len())
+	}
+
+	// 第一次重放遇到服务端错误时停止，等待下次重放
+	server := newFeishuServer(t, http.StatusServiceUnavailable)
+	r := newRetryNotifier("feishu", newTestFeishuNotifier(t, server.URL), testPolicy, queue, time.Hour)
+	defer r.shutdown()
+
+	r.replay()
+	if queue.len() != 2 {
+		t.Errorf("重放失败后队列中有 %d 条告警，期望保留 2 条", queue.len())
+	}
+	r.replay()
+	if queue.len() != 0 {
+		t.Errorf("重放成功后队列中仍有 %d 条告警", queue.len())
+	}
+	if got := server.received(); got != 3 {
+		t.Errorf("发送 %d 次，期望 3 次", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "feishu.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("队列清空后应删除队列文件")
+	}
+}
@@ -0,0 +1,138 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const slackTextLimit = 3000 // Slack 单个文本块最大字符数（代码块标记和转义字符也计算在内，截断时预留余量）
+
+// SlackNotifier Slack incoming webhook 通知器
+type SlackNotifier struct {
+	webhook string
+	format  string // 消息格式，card（默认，Block Kit）或 text
+	tmpl    *template.Template
+}
+
+// NewSlackNotifier 创建 Slack 通知器
+func NewSlackNotifier(cfg config.Notifier, tmpl *template.Template) *SlackNotifier {
+	return &SlackNotifier{
+		webhook: cfg.Webhook,
+		format:  cfg.Format,
+		tmpl:    tmpl,
+	}
+}
+
+// Send 发送 Slack 消息，Block Kit 消息被拒绝时降级为文本消息
+func (s *SlackNotifier) Send(a *alert.Alert) error {
+	message, err := alert.Render(s.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	if s.format != FormatText {
+		err := s.sendHTTPRequest(slackBlocksPayload(a, message))
+		if !shouldFallback(err) {
+			return err
+		}
+		log.Printf("Slack Block Kit 消息被拒绝，降级为文本消息: %v", err)
+	}
+
+	return s.sendHTTPRequest(map[string]interface{}{"text": message})
+}
+
+// sendHTTPRequest 发送HTTP请求
+func (s *SlackNotifier) sendHTTPRequest(payload map[string]interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	resp, err := httpClient.Post(s.webhook, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return checkSlackResponse(resp.StatusCode, body)
+}
+
+// slackBlocksPayload 构建 Block Kit 消息，以附件颜色区分告警类型，text 为通知预览使用的文本
+func slackBlocksPayload(a *alert.Alert, message string) map[string]interface{} {
+	var fields []map[string]string
+	for _, d := range alertDetails(a) {
+		// section 最多支持10个字段
+		if len(fields) == 10 {
+			break
+		}
+		fields = append(fields, map[string]string{
+			"type": "mrkdwn",
+			"text": "*" + d.label + "*\n" + slackEscape(d.value),
+		})
+	}
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": truncateRunes(a.Title, 150)},
+		},
+		{"type": "section", "fields": fields},
+		{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": codeBlock(truncateEscapedSlack(slackEscape(a.Line), slackTextLimit-32))},
+		},
+	}
+	if len(a.Samples) > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{
+				"type": "mrkdwn",
+				"text": "*样例*\n" + codeBlock(truncateEscapedSlack(slackEscape(strings.Join(a.Samples, "\n")), slackTextLimit-64)),
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"text": message,
+		"attachments": []map[string]interface{}{
+			{"color": htmlColor(a), "blocks": blocks},
+		},
+	}
+}
+
+// slackEscape 转义 mrkdwn 中的控制字符 &、<、>
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// truncateEscapedSlack 按字符数截断已转义的 mrkdwn 文本，不拆开末尾的 &amp; 等转义实体
+func truncateEscapedSlack(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	truncated := string([]rune(text)[:limit-1])
+	// 转义后的文本中 & 只会出现在实体开头
+	if i := strings.LastIndex(truncated, "&"); i >= 0 && !strings.Contains(truncated[i:], ";") {
+		truncated = truncated[:i]
+	}
+	return truncated + "…"
+}
+
+// truncateRunes 将文本截断到 limit 个字符以内，截断时以省略号结尾
+func truncateRunes(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
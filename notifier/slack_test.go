@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"log-monitor/alert"
+)
+
+func TestTruncateEscapedSlack(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"未超出限制", "a &lt;b&gt;", 20, "a &lt;b&gt;"},
+		{"不拆开转义实体", "abc&amp;def", 6, "abc…"},
+		{"完整的转义实体保留", "ab&lt;cdef", 8, "ab&lt;c…"},
+		{"按字符计数", "中文中文中文", 4, "中文中…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateEscapedSlack(tt.text, tt.limit); got != tt.want {
+				t.Errorf("truncateEscapedSlack(%q, %d) = %q，期望 %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackSectionLimit(t *testing.T) {
+	a := alert.Sample()
+	// 每个字符转义后变为 4~5 个字符
+	a.Line = strings.Repeat("<&>", slackTextLimit)
+	a.Samples = []string{a.Line}
+
+	payload := slackBlocksPayload(a, "message")
+	blocks := payload["attachments"].([]map[string]interface{})[0]["blocks"].([]map[string]interface{})
+	for _, block := range blocks {
+		text, ok := block["text"].(map[string]string)
+		if !ok || text["type"] != "mrkdwn" {
+			continue
+		}
+		if n := utf8.RuneCountInString(text["text"]); n > slackTextLimit {
+			t.Errorf("section 文本 %d 字符，超过 %d", n, slackTextLimit)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"strings"
+	"text/template"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+// FormatAdaptive Teams Adaptive Card 消息格式（Power Automate 工作流 webhook）
+const FormatAdaptive = "adaptive"
+
+// TeamsNotifier Microsoft Teams incoming webhook 通知器
+type TeamsNotifier struct {
+	webhook string
+	format  string // 消息格式，card（默认，MessageCard）、adaptive 或 text
+	tmpl    *template.Template
+}
+
+// NewTeamsNotifier 创建 Teams 通知器
+func NewTeamsNotifier(cfg config.Notifier, tmpl *template.Template) *TeamsNotifier {
+	return &TeamsNotifier{
+		webhook: cfg.Webhook,
+		format:  cfg.Format,
+		tmpl:    tmpl,
+	}
+}
+
+// Send 发送 Teams 消息，卡片消息被拒绝时降级为文本消息
+func (t *TeamsNotifier) Send(a *alert.Alert) error {
+	message, err := alert.Render(t.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	if t.format != FormatText {
+		payload := teamsMessageCard(a)
+		if t.format == FormatAdaptive {
+			payload = teamsAdaptiveCard(a)
+		}
+		err := t.sendHTTPRequest(payload)
+		if !shouldFallback(err) {
+			return err
+		}
+		log.Printf("Teams 卡片消息被拒绝，降级为文本消息: %v", err)
+	}
+
+	if t.format == FormatAdaptive {
+		return t.sendHTTPRequest(adaptiveCard([]map[string]interface{}{
+			{"type": "TextBlock", "text": message, "wrap": true},
+		}))
+	}
+	return t.sendHTTPRequest(map[string]interface{}{
+		"@type":    "MessageCard",
+		"@context": "http://schema.org/extensions",
+		"summary":  a.Title,
+		"text":     "<pre>" + html.EscapeString(message) + "</pre>",
+	})
+}
+
+// sendHTTPRequest 发送HTTP请求
+func (t *TeamsNotifier) sendHTTPRequest(payload map[string]interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	resp, err := httpClient.Post(t.webhook, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return checkTeamsResponse(resp.StatusCode, body)
+}
+
+// teamsMessageCard 构建 Office 365 连接器 MessageCard 消息
+func teamsMessageCard(a *alert.Alert) map[string]interface{} {
+	var facts []map[string]string
+	for _, d := range alertDetails(a) {
+		facts = append(facts, map[string]string{"name": d.label, "value": html.EscapeString(d.value)})
+	}
+
+	sections := []map[string]interface{}{
+		{"facts": facts, "text": "<pre>" + html.EscapeString(a.Line) + "</pre>"},
+	}
+	if len(a.Samples) > 0 {
+		sections = append(sections, map[string]interface{}{
+			"title": "样例",
+			"text":  "<pre>" + html.EscapeString(strings.Join(a.Samples, "\n")) + "</pre>",
+		})
+	}
+
+	return map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "http://schema.org/extensions",
+		"themeColor": strings.TrimPrefix(htmlColor(a), "#"),
+		"summary":    a.Title,
+		"title":      a.Title,
+		"sections":   sections,
+	}
+}
+
+// teamsAdaptiveCard 构建 Adaptive Card 消息
+func teamsAdaptiveCard(a *alert.Alert) map[string]interface{} {
+	var facts []map[string]string
+	for _, d := range alertDetails(a) {
+		facts = append(facts, map[string]string{"title": d.label, "value": d.value})
+	}
+
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": a.Title, "weight": "Bolder", "size": "Medium", "color": adaptiveColor(a), "wrap": true},
+		{"type": "FactSet", "facts": facts},
+		{"type": "TextBlock", "text": a.Line, "fontType": "Monospace", "wrap": true},
+	}
+	if len(a.Samples) > 0 {
+		body = append(body,
+			map[string]interface{}{"type": "TextBlock", "text": "样例", "weight": "Bolder"},
+			map[string]interface{}{"type": "TextBlock", "text": strings.Join(a.Samples, "\n"), "fontType": "Monospace", "wrap": true},
+		)
+	}
+	return adaptiveCard(body)
+}
+
+// adaptiveCard 将 Adaptive Card 内容包装为 Teams 消息
+func adaptiveCard(body []map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+					"msteams": map[string]string{"width": "Full"},
+				},
+			},
+		},
+	}
+}
+
+// adaptiveColor Adaptive Card 标题颜色，与飞书卡片的颜色规则一致
+func adaptiveColor(a *alert.Alert) string {
+	switch headerColor(a) {
+	case "green":
+		return "Good"
+	case "orange":
+		return "Warning"
+	case "blue":
+		return "Accent"
+	default:
+		return "Attention"
+	}
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const (
+	defaultTelegramAPIURL = "https://api.telegram.org" // 默认 Bot API 地址
+	telegramTextLimit     = 4096                       // 单条消息最大字符数
+)
+
+// TelegramNotifier Telegram 机器人通知器
+type TelegramNotifier struct {
+	endpoint string // sendMessage 接口地址，包含 bot token
+	chatID   string
+	format   string // 消息格式，markdown（默认，MarkdownV2）或 text
+	tmpl     *template.Template
+}
+
+// NewTelegramNotifier 创建 Telegram 通知器
+func NewTelegramNotifier(cfg config.Notifier, tmpl *template.Template) *TelegramNotifier {
+	apiURL := strings.TrimSuffix(cfg.Telegram.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	}
+	return &TelegramNotifier{
+		endpoint: apiURL + "/bot" + cfg.Telegram.BotToken + "/sendMessage",
+		chatID:   cfg.Telegram.ChatID,
+		format:   cfg.Format,
+		tmpl:     tmpl,
+	}
+}
+
+// Send 发送 Telegram 消息，MarkdownV2 消息被拒绝时降级为文本消息
+func (t *TelegramNotifier) Send(a *alert.Alert) error {
+	message, err := alert.Render(t.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	if t.format != FormatText {
+		err := t.sendHTTPRequest(map[string]interface{}{
+			"chat_id":                  t.chatID,
+			"text":                     telegramMarkdown(a),
+			"parse_mode":               "MarkdownV2",
+			"disable_web_page_preview": true,
+		})
+		if !shouldFallback(err) {
+			return err
+		}
+		log.Printf("Telegram MarkdownV2 消息被拒绝，降级为文本消息: %v", err)
+	}
+
+	return t.sendHTTPRequest(map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     truncateRunes(message, telegramTextLimit),
+		"disable_web_page_preview": true,
+	})
+}
+
+// sendHTTPRequest 发送HTTP请求
+func (t *TelegramNotifier) sendHTTPRequest(payload map[string]interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	resp, err := httpClient.Post(t.endpoint, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		// 错误信息中包含请求地址，避免 bot token 出现在日志中
+		return transportError(fmt.Errorf("%s", strings.ReplaceAll(err.Error(), t.endpoint, "<telegram api>")))
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return checkTelegramResponse(resp.StatusCode, body)
+}
+
+// telegramMarkdown 构建 MarkdownV2 消息，日志内容放在代码块中
+func telegramMarkdown(a *alert.Alert) string {
+	var b strings.Builder
+	b.WriteString("*" + escapeMarkdownV2(a.Title) + "*\n\n")
+	for _, d := range alertDetails(a) {
+		b.WriteString("*" + escapeMarkdownV2(d.label) + "*: " + escapeMarkdownV2(d.value) + "\n")
+	}
+
+	// 转义会增加长度，先转义再按字符数截断，为标题、字段和代码块标记预留长度
+	const fence = "```"
+	limit := telegramTextLimit - utf8.RuneCountInString(b.String()) - utf8.RuneCountInString(fence+"\n\n"+fence)
+	if limit < 256 {
+		limit = 256
+	}
+	content := a.Line
+	if len(a.Samples) > 0 {
+		content += "\n\n样例:\n" + strings.Join(a.Samples, "\n")
+	}
+	b.WriteString(fence + "\n" + truncateEscaped(escapeMarkdownV2Code(content), limit) + "\n" + fence)
+	return b.String()
+}
+
+// truncateEscaped 按字符数截断已转义的文本，不拆开末尾的转义序列
+func truncateEscaped(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)[:limit-1]
+	backslashes := 0
+	for i := len(runes) - 1; i >= 0 && runes[i] == '\\'; i-- {
+		backslashes++
+	}
+	if backslashes%2 == 1 {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// markdownV2Escaper MarkdownV2 普通文本中需要转义的字符
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// escapeMarkdownV2 转义 MarkdownV2 普通文本
+func escapeMarkdownV2(text string) string {
+	return markdownV2Escaper.Replace(text)
+}
+
+// escapeMarkdownV2Code 转义 MarkdownV2 代码块内容，代码块中只需要转义 ` 和 \
+func escapeMarkdownV2Code(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(text)
+}
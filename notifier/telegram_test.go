@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"log-monitor/alert"
+)
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"中文内容", "中文内容"},
+		{"/var/log/app.log", `/var/log/app\.log`},
+		{"a_b*c", `a\_b\*c`},
+		{"[link](http://x)", `\[link\]\(http://x\)`},
+		{"~`>#+-=|{}.!", "\\~\\`\\>\\#\\+\\-\\=\\|\\{\\}\\.\\!"},
+		{`C:\logs`, `C:\\logs`},
+		{"2024-01-15 14:30:25", `2024\-01\-15 14:30:25`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeMarkdownV2(tt.text); got != tt.want {
+			t.Errorf("escapeMarkdownV2(%q) = %q，期望 %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeMarkdownV2Code(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"at main.run(main.go:12)", "at main.run(main.go:12)"},
+		{"use `go test`", "use \\`go test\\`"},
+		{`C:\app\data`, `C:\\app\\data`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeMarkdownV2Code(tt.text); got != tt.want {
+			t.Errorf("escapeMarkdownV2Code(%q) = %q，期望 %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateEscaped(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"未超出限制", `a\\b`, 10, `a\\b`},
+		{"不拆开转义序列", `abc\\d`, 5, "abc…"},
+		{"完整的转义序列保留", `ab\\cd`, 5, `ab\\…`},
+		{"按字符计数", "中文中文中文", 4, "中文中…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateEscaped(tt.text, tt.limit); got != tt.want {
+				t.Errorf("truncateEscaped(%q, %d) = %q，期望 %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTelegramMarkdownLimit(t *testing.T) {
+	a := alert.Sample()
+	// 转义后长度翻倍的内容
+	a.Line = strings.Repeat("\\`", telegramTextLimit)
+
+	text := telegramMarkdown(a)
+	if n := utf8.RuneCountInString(text); n > telegramTextLimit {
+		t.Errorf("消息长度 %d 超过 %d 字符", n, telegramTextLimit)
+	}
+	if !strings.HasSuffix(text, "…\n```") {
+		t.Errorf("日志内容应被截断并保留代码块结束标记")
+	}
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+// WebhookNotifier 通用 HTTP webhook 通知器，用于对接自建的事件平台
+type WebhookNotifier struct {
+	url         string
+	method      string
+	headers     map[string]string
+	bearerToken string
+	contentType string
+	body        *template.Template // 请求体模板，为nil时发送告警的 JSON
+}
+
+// NewWebhookNotifier 创建通用 webhook 通知器
+func NewWebhookNotifier(cfg config.Notifier) (*WebhookNotifier, error) {
+	w := &WebhookNotifier{
+		url:         cfg.Webhook,
+		method:      strings.ToUpper(cfg.Method),
+		headers:     cfg.Headers,
+		bearerToken: cfg.BearerToken,
+		contentType: cfg.ContentType,
+	}
+	if w.method == "" {
+		w.method = http.MethodPost
+	}
+	if w.contentType == "" {
+		w.contentType = "application/json"
+	}
+	if cfg.Body != "" {
+		tmpl, err := alert.ParseTemplate("body", cfg.Body)
+		if err != nil {
+			return nil, fmt.Errorf("请求体模板无效: %v", err)
+		}
+		w.body = tmpl
+	}
+	return w, nil
+}
+
+// Send 发送告警到 webhook，2xx 响应视为成功
+func (w *WebhookNotifier) Send(a *alert.Alert) error {
+	var data []byte
+	if w.body != nil {
+		body, err := alert.Render(w.body, a)
+		if err != nil {
+			return err
+		}
+		data = []byte(body)
+	} else {
+		var err error
+		if data, err = json.Marshal(a); err != nil {
+			return fmt.Errorf("序列化消息失败: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest(w.method, w.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建HTTP请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	if w.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.bearerToken)
+	}
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"log-monitor/alert"
+	"log-monitor/config"
+)
+
+const (
+	wecomTextLimit     = 2048 // 企业微信文本消息内容最大字节数
+	wecomMarkdownLimit = 4096 // 企业微信 markdown 消息内容最大字节数
+)
+
+// WecomNotifier 企业微信群机器人通知器
+type WecomNotifier struct {
+	webhook string
+	format  string         // 消息格式，text 或 markdown
+	mention *alert.Mention // 默认 @提醒
+	tmpl    *template.Template
+}
+
+// NewWecomNotifier 创建企业微信通知器
+func NewWecomNotifier(cfg config.Notifier, tmpl *template.Template) *WecomNotifier {
+	return &WecomNotifier{
+		webhook: cfg.Webhook,
+		format:  cfg.Format,
+		mention: cfg.Mention.AlertMention(),
+		tmpl:    tmpl,
+	}
+}
+
+// Send 发送企业微信消息，markdown 消息被拒绝时降级为文本消息
+func (w *WecomNotifier) Send(a *alert.Alert) error {
+	message, err := alert.Render(w.tmpl, a)
+	if err != nil {
+		return err
+	}
+
+	mention := mentionFor(a, w.mention)
+
+	if w.format == FormatMarkdown {
+		content := markdownMessage(a)
+		if !mention.Empty() {
+			content = appendLine(content, wecomAtMarkdown(mention))
+		}
+		payload := map[string]interface{}{
+			"msgtype": "markdown",
+			"markdown": map[string]string{
+				"content": truncateBytes(content, wecomMarkdownLimit),
+			},
+		}
+		err := w.sendHTTPRequest(payload)
+		if !shouldFallback(err) {
+			return err
+		}
+		log.Printf("企业微信markdown消息被拒绝，降级为文本消息: %v", err)
+	}
+
+	text := map[string]interface{}{
+		"content": truncateBytes(message, wecomTextLimit),
+	}
+	if !mention.Empty() {
+		// 文本消息通过 mentioned_list/mentioned_mobile_list 提醒，@所有人 使用 "@all"
+		mentioned := append([]string{}, mention.UserIDs...)
+		if mention.All {
+			mentioned = append(mentioned, "@all")
+		}
+		text["mentioned_list"] = mentioned
+		text["mentioned_mobile_list"] = mention.Mobiles
+	}
+
+	return w.sendHTTPRequest(map[string]interface{}{
+		"msgtype": "text",
+		"text":    text,
+	})
+}
+
+// sendHTTPRequest 发送HTTP请求
+func (w *WecomNotifier) sendHTTPRequest(payload map[string]interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %v", err)
+	}
+
+	resp, err := httpClient.Post(w.webhook, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	// 企业微信对限流、内容错误等同样返回 200，需要检查响应中的错误码
+	body, err := readResponse(resp)
+	if err != nil {
+		return err
+	}
+	return checkWecomResponse(body)
+}
+
+// wecomAtMarkdown 企业微信 markdown 消息中的 @ 标签，markdown 消息只支持按 userid 提醒
+func wecomAtMarkdown(m *alert.Mention) string {
+	var tags []string
+	for _, id := range m.UserIDs {
+		tags = append(tags, "<@"+id+">")
+	}
+	return strings.Join(tags, " ")
+}
+
+// truncateBytes 将文本截断到 limit 字节以内，不截断多字节字符，截断时追加提示
+func truncateBytes(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+
+	const suffix = "\n...（内容过长已截断）"
+	cut := limit - len(suffix)
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + suffix
+}
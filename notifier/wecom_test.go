@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateBytes(t *testing.T) {
+	const suffix = "\n...（内容过长已截断）"
+
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"未超出限制", "hello", 10, "hello"},
+		{"恰好等于限制", "hello", 5, "hello"},
+		{"ASCII 超出限制", strings.Repeat("a", 100), len(suffix) + 10, strings.Repeat("a", 10) + suffix},
+		// 每个汉字3字节，截断位置落在第4个汉字中间时退回到第3个汉字之后
+		{"不截断多字节字符", strings.Repeat("中", 20), len(suffix) + 10, strings.Repeat("中", 3) + suffix},
+		{"截断位置恰好在字符边界", strings.Repeat("中", 20), len(suffix) + 9, strings.Repeat("中", 3) + suffix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateBytes(tt.text, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateBytes() = %q，期望 %q", got, tt.want)
+			}
+			if len(got) > tt.limit {
+				t.Errorf("截断后长度 %d 超过限制 %d", len(got), tt.limit)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("截断后不是合法的 UTF-8: %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"log-monitor/config"
+)
+
+// runSilence 添加运行时告警屏蔽，写入配置的 silence_file，运行中的监控进程会自动加载
+//
+//	log-monitor silence -config config.yaml -duration 2h -files "/var/log/app/*.log" -comment "发布"
+//	log-monitor silence -config config.yaml -list
+//	log-monitor silence -config config.yaml -expire adhoc-20240601-220000
+func runSilence(args []string) {
+	fs := flag.NewFlagSet("silence", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "配置文件路径")
+	duration := fs.Duration("duration", 0, "屏蔽时长，如 30m、2h")
+	name := fs.String("name", "", "屏蔽名称，默认为 adhoc-<开始时间>")
+	files := fs.String("files", "", "屏蔽的日志文件，多个用逗号分隔，支持通配符，默认屏蔽所有文件")
+	rules := fs.String("rules", "", "屏蔽的告警规则，多个用逗号分隔，默认屏蔽所有规则")
+	notifiers := fs.String("notifiers", "", "屏蔽的通知器名称，多个用逗号分隔，默认屏蔽所有通知器")
+	comment := fs.String("comment", "", "备注")
+	summary := fs.Bool("summary", false, "屏蔽结束时发送屏蔽期间的告警汇总")
+	list := fs.Bool("list", false, "列出运行时告警屏蔽")
+	expire := fs.String("expire", "", "提前结束指定名称的运行时告警屏蔽")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.SilenceFile == "" {
+		log.Fatalf("配置文件中没有配置 silence_file")
+	}
+
+	silences, err := config.LoadSilences(cfg.SilenceFile)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *list {
+		for _, s := range silences {
+			fmt.Println(describeSilence(s))
+		}
+		return
+	}
+
+	if *expire != "" {
+		var kept []config.Silence
+		for _, s := range silences {
+			if s.Name != *expire {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) == len(silences) {
+			log.Fatalf("运行时告警屏蔽 %s 不存在", *expire)
+		}
+		if err := config.SaveSilences(cfg.SilenceFile, kept); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("已结束告警屏蔽: %s\n", *expire)
+		return
+	}
+
+	if *duration <= 0 {
+		log.Fatalf("需要通过 -duration 指定屏蔽时长")
+	}
+
+	// 清理已结束的一次性屏蔽
+	now := time.Now()
+	var kept []config.Silence
+	for _, s := range silences {
+		if s.Recurring() || s.EndsAt.After(now) {
+			kept = append(kept, s)
+		}
+	}
+
+	s := config.Silence{
+		Name:      *name,
+		Comment:   *comment,
+		StartsAt:  now.Truncate(time.Second),
+		EndsAt:    now.Add(*duration).Truncate(time.Second),
+		Files:     splitList(*files),
+		Rules:     splitList(*rules),
+		Notifiers: splitList(*notifiers),
+		Summary:   *summary,
+	}
+	if s.Name == "" {
+		s.Name = "adhoc-" + now.Format("20060102-150405")
+	}
+	if err := s.Validate(); err != nil {
+		log.Fatalf("告警屏蔽%v", err)
+	}
+	if err := cfg.ValidateSilenceScope(&s); err != nil {
+		log.Fatalf("告警屏蔽%v", err)
+	}
+	for _, existing := range append(cfg.Silences, kept...) {
+		if existing.Name == s.Name {
+			log.Fatalf("告警屏蔽名称 %s 已存在", s.Name)
+		}
+	}
+
+	if err := config.SaveSilences(cfg.SilenceFile, append(kept, s)); err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("已添加告警屏蔽: %s\n", describeSilence(s))
+}
+
+// describeSilence 告警屏蔽的单行描述
+func describeSilence(s config.Silence) string {
+	var b strings.Builder
+	b.WriteString(s.Name)
+	if s.Recurring() {
+		fmt.Fprintf(&b, " 每天 %s-%s", s.StartTime, s.EndTime)
+		if len(s.Weekdays) > 0 {
+			b.WriteString(" " + strings.Join(s.Weekdays, ","))
+		}
+		if s.Timezone != "" {
+			b.WriteString(" " + s.Timezone)
+		}
+	} else {
+		fmt.Fprintf(&b, " %s ~ %s", s.StartsAt.Format("2006-01-02 15:04:05"), s.EndsAt.Format("2006-01-02 15:04:05"))
+	}
+	if len(s.Files) > 0 {
+		b.WriteString(" 文件: " + strings.Join(s.Files, ","))
+	}
+	if len(s.Rules) > 0 {
+		b.WriteString(" 规则: " + strings.Join(s.Rules, ","))
+	}
+	if len(s.Notifiers) > 0 {
+		b.WriteString(" 通知器: " + strings.Join(s.Notifiers, ","))
+	}
+	if s.Comment != "" {
+		b.WriteString(" (" + s.Comment + ")")
+	}
+	return b.String()
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}